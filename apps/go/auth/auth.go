@@ -0,0 +1,61 @@
+// Package auth issues and verifies the HMAC-signed access keys used to
+// gate audio playback, modeled on the AWS S3 access-key pattern: a scoped
+// {accessKey, secretKey} pair signs requests instead of the app handing
+// out public bucket URLs.
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// KeyLen is the length of the generated access key.
+	KeyLen = 20
+	// SecretLen is the length of the generated secret key.
+	SecretLen = 40
+
+	keyAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+)
+
+// Key is an HMAC credential scoped to a single PDF, with an optional expiry.
+type Key struct {
+	AccessKey string
+	SecretKey string
+	PdfId     string
+	ExpiresAt time.Time
+}
+
+// Generate creates a new access/secret key pair scoped to pdfId, valid
+// until expiresAt (zero value means no expiry).
+func Generate(pdfId string, expiresAt time.Time) (Key, error) {
+	accessKey, err := randomString(KeyLen)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to generate access key: %v", err)
+	}
+	secretKey, err := randomString(SecretLen)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to generate secret key: %v", err)
+	}
+
+	return Key{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PdfId:     pdfId,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func randomString(n int) (string, error) {
+	out := make([]byte, n)
+	for i := range out {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(keyAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		out[i] = keyAlphabet[idx.Int64()]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Scheme is the Authorization header scheme this package issues and checks.
+const Scheme = "OR-HMAC-SHA256"
+
+// clockSkew is how far a request's Date header may drift from server time.
+const clockSkew = 5 * time.Minute
+
+// Sign computes the signature for a method+path+date tuple the same way
+// both the client and VerifyRequest do, so the two stay in lockstep.
+func Sign(secretKey, method, path, date string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(method + "\n" + path + "\n" + date))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthHeader builds the Authorization header value a client should send.
+func AuthHeader(accessKey, secretKey, method, path, date string) string {
+	sig := Sign(secretKey, method, path, date)
+	return fmt.Sprintf("%s Credential=%s, Signature=%s", Scheme, accessKey, sig)
+}
+
+// KeyLookup resolves an access key to its secret and scope. It returns
+// found=false if the access key is unknown.
+type KeyLookup func(accessKey string) (secretKey string, pdfId string, expiresAt time.Time, found bool)
+
+// VerifyRequest checks the Authorization and Date headers on r against
+// lookup, returning the pdfId the matched key is scoped to. now is passed
+// in explicitly so it's easy to unit test with a fixed clock.
+func VerifyRequest(r *http.Request, lookup KeyLookup, now time.Time) (pdfId string, err error) {
+	accessKey, signature, err := parseAuthorizationHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		return "", err
+	}
+
+	dateHeader := r.Header.Get("Date")
+	if dateHeader == "" {
+		return "", fmt.Errorf("missing Date header")
+	}
+	reqDate, err := time.Parse(http.TimeFormat, dateHeader)
+	if err != nil {
+		return "", fmt.Errorf("invalid Date header: %v", err)
+	}
+	if diff := now.Sub(reqDate); diff > clockSkew || diff < -clockSkew {
+		return "", fmt.Errorf("request Date is outside the allowed %s clock-skew window", clockSkew)
+	}
+
+	secretKey, scopedPdfId, expiresAt, found := lookup(accessKey)
+	if !found {
+		return "", fmt.Errorf("unknown access key")
+	}
+	if !expiresAt.IsZero() && now.After(expiresAt) {
+		return "", fmt.Errorf("access key has expired")
+	}
+
+	expected := Sign(secretKey, r.Method, r.URL.Path, dateHeader)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", fmt.Errorf("signature mismatch")
+	}
+
+	return scopedPdfId, nil
+}
+
+func parseAuthorizationHeader(header string) (accessKey, signature string, err error) {
+	if header == "" {
+		return "", "", fmt.Errorf("missing Authorization header")
+	}
+
+	prefix := Scheme + " "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", fmt.Errorf("unsupported Authorization scheme")
+	}
+
+	fields := strings.Split(strings.TrimPrefix(header, prefix), ", ")
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Credential":
+			accessKey = kv[1]
+		case "Signature":
+			signature = kv[1]
+		}
+	}
+
+	if accessKey == "" || signature == "" {
+		return "", "", fmt.Errorf("malformed Authorization header")
+	}
+	return accessKey, signature, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http/httputil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucsky/cuid"
+)
+
+var accessLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// sensitiveHeaderPattern matches the header lines a reproduced request must
+// redact before it's written to disk: Authorization, the TTS API key, the
+// admin key guarding POST /keys, and the AWS credentials loadEnv() logs about.
+var sensitiveHeaderPattern = regexp.MustCompile(`(?im)^(Authorization|X-Tts-Api-Key|X-Admin-Key|Aws-Access-Key|Aws-Secret-Access-Key):\s*(.+)$`)
+
+// requestIDMiddleware assigns every inbound request a cuid so it can be
+// correlated across the access log, any reproduced .http file, and
+// ChunkProcessor.lastError when a background goroutine fails.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := cuid.New()
+		c.Set("request_id", requestID)
+		c.Next()
+	}
+}
+
+// reproduceMiddleware captures every inbound request in raw HTTP/1.1 wire
+// format under ./debug/requests/<cuid>.http when DEBUG_REPRODUCE=1, so a
+// TTS-provider failure can be replayed locally with `curl --data-binary @...`.
+func reproduceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if os.Getenv("DEBUG_REPRODUCE") != "1" {
+			c.Next()
+			return
+		}
+
+		dump, err := httputil.DumpRequest(c.Request, true)
+		if err != nil {
+			log.Printf("Failed to dump request for reproduction: %v", err)
+			c.Next()
+			return
+		}
+
+		requestID, _ := c.Get("request_id")
+		if err := writeReproFile(fmt.Sprintf("%v", requestID), dump); err != nil {
+			log.Printf("Failed to write reproduction file: %v", err)
+		}
+
+		c.Next()
+	}
+}
+
+func writeReproFile(requestID string, rawRequest []byte) error {
+	dir := "./debug/requests"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	masked := sensitiveHeaderPattern.ReplaceAllStringFunc(string(rawRequest), func(line string) string {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return line
+		}
+		return fmt.Sprintf("%s: %s", parts[0], maskString(strings.TrimSpace(parts[1])))
+	})
+
+	return os.WriteFile(path.Join(dir, requestID+".http"), []byte(masked), 0644)
+}
+
+// accessLogMiddleware emits one structured log line per request with the
+// fields needed to debug a TTS-provider failure after the fact.
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		requestID, _ := c.Get("request_id")
+		pdfId := c.Query("pdfId")
+		if pdfId == "" {
+			pdfId = c.Request.FormValue("pdfId")
+		}
+
+		accessLogger.Info("http_request",
+			"request_id", requestID,
+			"pdf_id", pdfId,
+			"chunk", c.Param("chunk"),
+			"provider", c.GetHeader("X-TTS-Provider"),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"upstream_status", c.Writer.Status(),
+			"bytes_out", c.Writer.Size(),
+			"path", c.Request.URL.Path,
+			"method", c.Request.Method,
+		)
+	}
+}
+
+func requestIDFromContext(c *gin.Context) string {
+	if requestID, ok := c.Get("request_id"); ok {
+		return fmt.Sprintf("%v", requestID)
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexandergekov/open-reader/apps/go/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// presignTTL is how long a presigned/HMAC-proxied audio URL stays valid
+// once handed to a client.
+const presignTTL = 5 * time.Minute
+
+type createKeyRequest struct {
+	PdfId     string `json:"pdfId"`
+	ExpiresIn int64  `json:"expiresInSeconds"` // 0 means no expiry
+}
+
+type createKeyResponse struct {
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	PdfId     string `json:"pdfId"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// createKeyHandler is an admin endpoint that mints an HMAC credential
+// scoped to a single pdfId. The secret is only ever returned here; Postgres
+// stores it AES-256-GCM encrypted under ACCESS_KEY_ENC_SECRET rather than
+// in plaintext, so a leaked access_keys dump alone doesn't hand over usable
+// signing keys. This has to be reversible encryption rather than a hash:
+// VerifyRequest needs the actual secret bytes the client signed with to
+// check a signature, not a digest of them.
+func createKeyHandler(c *gin.Context) {
+	var req createKeyRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PdfId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pdfId is required"})
+		return
+	}
+
+	encKey, err := auth.EncryptionKeyFromEnv()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+	}
+
+	key, err := auth.Generate(req.PdfId, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	encryptedSecret, err := auth.EncryptSecret(key.SecretKey, encKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encrypt secret: %v", err)})
+		return
+	}
+
+	if db != nil {
+		_, err := db.Exec(context.Background(),
+			`INSERT INTO access_keys (access_key, secret_key, pdf_id, expires_at, created_at)
+			 VALUES ($1, $2, $3, $4, $5)`,
+			key.AccessKey, encryptedSecret, key.PdfId, nullableTime(expiresAt), time.Now(),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to persist key: %v", err)})
+			return
+		}
+	}
+
+	resp := createKeyResponse{AccessKey: key.AccessKey, SecretKey: key.SecretKey, PdfId: key.PdfId}
+	if !expiresAt.IsZero() {
+		resp.ExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// lookupAccessKey resolves an access key against the access_keys table for
+// auth.VerifyRequest, decrypting the stored secret back to the raw bytes
+// the client originally signed with.
+func lookupAccessKey(accessKey string) (secretKey string, pdfId string, expiresAt time.Time, found bool) {
+	if db == nil {
+		return "", "", time.Time{}, false
+	}
+
+	var encryptedSecret string
+	var expires *time.Time
+	row := db.QueryRow(context.Background(),
+		`SELECT secret_key, pdf_id, expires_at FROM access_keys WHERE access_key = $1`, accessKey)
+	if err := row.Scan(&encryptedSecret, &pdfId, &expires); err != nil {
+		return "", "", time.Time{}, false
+	}
+
+	encKey, err := auth.EncryptionKeyFromEnv()
+	if err != nil {
+		return "", "", time.Time{}, false
+	}
+	secretKey, err = auth.DecryptSecret(encryptedSecret, encKey)
+	if err != nil {
+		return "", "", time.Time{}, false
+	}
+
+	if expires != nil {
+		expiresAt = *expires
+	}
+	return secretKey, pdfId, expiresAt, true
+}
+
+// hmacAuthMiddleware requires every request to carry a valid
+// "Authorization: OR-HMAC-SHA256 Credential=…, Signature=…" header scoped
+// to the pdfId being accessed, so audio URLs are never publicly guessable.
+func hmacAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pdfId, err := auth.VerifyRequest(c.Request, lookupAccessKey, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		requestedPdfId := c.Query("pdfId")
+		if requestedPdfId == "" {
+			requestedPdfId = strings.TrimSpace(c.Param("pdfId"))
+		}
+		if requestedPdfId != "" && requestedPdfId != pdfId {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access key is not scoped to this pdfId"})
+			return
+		}
+
+		c.Set("pdfId", pdfId)
+		c.Next()
+	}
+}
+
+// adminAuthMiddleware gates admin-only endpoints (currently just POST
+// /keys) behind a static X-Admin-Key header checked against ADMIN_API_KEY.
+// It fails closed: if ADMIN_API_KEY isn't set, the endpoint is disabled
+// rather than silently accepting any (or no) key.
+func adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := os.Getenv("ADMIN_API_KEY")
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin API is not configured"})
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Key")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin key"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -9,23 +9,23 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/alexandergekov/open-reader/apps/go/storage"
 	"github.com/alexandergekov/open-reader/apps/go/tts"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	"github.com/ledongthuc/pdf"
-	"github.com/lucsky/cuid"
-	"github.com/sentencizer/sentencizer"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 var db *pgx.Conn
@@ -113,6 +113,15 @@ func loadEnv() {
 	log.Printf("AWS_SECRET_ACCESS_KEY=%s", maskString(os.Getenv("AWS_SECRET_ACCESS_KEY")))
 }
 
+// storageBackendName reports which STORAGE_BACKEND is active, defaulting to "s3".
+func storageBackendName() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "s3"
+	}
+	return backend
+}
+
 // Helper function to mask sensitive strings
 func maskString(s string) string {
 	if len(s) == 0 {
@@ -151,10 +160,16 @@ type ChunkProcessor struct {
 	stopProcess chan bool      // Channel to stop processing
 	filename    string        // Store the current file's name
 	settings    TTSSettings   // Store TTS settings
-	s3Client    *s3.S3       // AWS S3 client
-	bucketName  string       // AWS S3 bucket name
+	blob        storage.Blob // Object-storage backend (s3/oss/gcs/fs)
 	chunkIDs    []string     // Store chunk DB IDs from Nuxt (unused now)
 	pdfId       string       // Store the current pdfId
+	requestID   string       // request_id of the HTTP call that started processing, for tracing
+	subMutex    sync.Mutex              // Guards subscribers
+	subscribers map[string][]chan ChunkEvent // SSE listeners keyed by pdfId
+	sqsClient   *sqs.SQS                     // Set when SQS_QUEUE_URL is configured
+	sqsQueueURL string                       // Destination/source queue for distributed chunk processing
+	chunkSizes  map[int]int64                // Byte size of each uploaded chunk's audio, for range-stitched streaming
+	pool        *genPool                     // Bounded worker pool generating chunks by proximity to currentIdx
 }
 
 type UploadResponse struct {
@@ -168,6 +183,46 @@ type TTSSettings struct {
 	APIKey   string `json:"apiKey"`
 	Model    string `json:"model"`
 	Voice    string `json:"voice"`
+
+	// Storage knobs for the uploaded audio object, passed through to
+	// storage.PutOptions. All optional; see storage.PutOptions for defaults.
+	StorageACL           string            `json:"storageAcl"`
+	ServerSideEncryption string            `json:"serverSideEncryption"`
+	SSECustomerKey       string            `json:"sseCustomerKey"`
+	SSECustomerKeyMD5    string            `json:"sseCustomerKeyMd5"`
+	StorageClass         string            `json:"storageClass"`
+	CacheControl         string            `json:"cacheControl"`
+	ContentDisposition   string            `json:"contentDisposition"`
+	Metadata             map[string]string `json:"metadata"`
+	// PresignTTLSeconds overrides presignTTL for this request's audio URLs
+	// (e.g. a longer-lived link for offline/download use). 0 uses the default.
+	PresignTTLSeconds int64 `json:"presignTtlSeconds"`
+
+	// WorkerPoolSize overrides GEN_WORKER_POOL_SIZE for this book's chunk
+	// generation; LookaheadDepth overrides GEN_LOOKAHEAD_DEPTH. Both 0 means
+	// "use the env default/fallback".
+	WorkerPoolSize int `json:"workerPoolSize"`
+	LookaheadDepth int `json:"lookaheadDepth"`
+
+	// Normalize EBU R128-normalizes every generated chunk to TargetLUFS (or
+	// tts.DefaultTargetLUFS if that's 0) as it's generated, so chunks don't
+	// jump in volume at playback time the way an un-normalized merge would.
+	// "ebu-r128" is the only supported value today; empty disables it.
+	Normalize  string  `json:"normalize"`
+	TargetLUFS float64 `json:"targetLufs"`
+}
+
+func (s TTSSettings) putOptions() storage.PutOptions {
+	return storage.PutOptions{
+		ACL:                  s.StorageACL,
+		ServerSideEncryption: s.ServerSideEncryption,
+		SSECustomerKey:       s.SSECustomerKey,
+		SSECustomerKeyMD5:    s.SSECustomerKeyMD5,
+		StorageClass:         s.StorageClass,
+		CacheControl:         s.CacheControl,
+		ContentDisposition:   s.ContentDisposition,
+		Metadata:             s.Metadata,
+	}
 }
 
 func main() {
@@ -176,41 +231,13 @@ func main() {
 	// Get API key but don't require it
 	apiKey := os.Getenv("ELEVENLABS_API_KEY")
 
-	// Get AWS configuration
-	awsRegion := os.Getenv("AWS_REGION")
-	if awsRegion == "" {
-		awsRegion = "us-east-1" // Default region
-	}
-
-	bucketName := os.Getenv("AWS_S3_BUCKET")
-	if bucketName == "" {
-		log.Fatal("AWS_S3_BUCKET environment variable is required")
-	}
-
-	log.Printf("AWS Configuration - Region: %s, Bucket: %s", awsRegion, bucketName)
-
-	// Initialize AWS session
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String(awsRegion),
-		Credentials: credentials.NewStaticCredentials(
-			os.Getenv("AWS_ACCESS_KEY"),
-			os.Getenv("AWS_SECRET_ACCESS_KEY"),
-			"",
-		),
-	}))
-
-	// Initialize S3 client
-	s3Client := s3.New(sess)
-
-	// Test S3 connection and bucket access
-	_, err := s3Client.HeadBucket(&s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
+	// Initialize the storage backend (STORAGE_BACKEND=s3|oss|gcs|fs, default s3)
+	blob, err := storage.New()
 	if err != nil {
-		log.Fatalf("Failed to access S3 bucket %s: %v", bucketName, err)
+		log.Fatalf("Failed to initialize storage backend: %v", err)
 	}
 
-	log.Printf("Successfully connected to S3 bucket: %s", bucketName)
+	log.Printf("Successfully initialized storage backend: %s", storageBackendName())
 
 	// Initialize the processor
 	processor = &ChunkProcessor{
@@ -219,12 +246,43 @@ func main() {
 		client:     &http.Client{Timeout: 30 * time.Second},
 		apiKey:     apiKey,
 		audioCache: make(map[string][]byte),
-		s3Client:   s3Client,
-		bucketName: bucketName, // Make sure bucketName is set
+		blob:       blob,
+		subscribers: make(map[string][]chan ChunkEvent),
+		chunkSizes: make(map[int]int64),
+		pool:       newGenPool(),
 	}
+	processor.pool.start(processor, defaultWorkerPoolSize())
 
-	// Log processor initialization
-	log.Printf("Initialized processor with bucket: %s", processor.bucketName)
+	if queueURL := os.Getenv("SQS_QUEUE_URL"); queueURL != "" {
+		sqsClient, err := newSQSClient()
+		if err != nil {
+			log.Fatalf("Failed to initialize SQS client: %v", err)
+		}
+		processor.sqsClient = sqsClient
+		processor.sqsQueueURL = queueURL
+		log.Printf("SQS distributed processing enabled, queue: %s", queueURL)
+	}
+
+	// WORKER_MODE=1 runs this process purely as an SQS consumer, so TTS
+	// compute can scale independently of the API pods handling uploads.
+	if os.Getenv("WORKER_MODE") == "1" {
+		if err := initDB(); err != nil {
+			log.Fatalf("Failed to connect to DB: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigCh
+			log.Printf("Worker shutting down...")
+			cancel()
+		}()
+
+		log.Printf("Starting in worker mode")
+		processor.RunWorker(ctx)
+		return
+	}
 
 	// Start a goroutine to periodically clean old cache entries
 	go func() {
@@ -243,6 +301,9 @@ func main() {
 
 	r := gin.Default()
 
+	r.Use(requestIDMiddleware())
+	r.Use(reproduceMiddleware())
+
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -252,12 +313,22 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	r.POST("/upload", uploadHandler)
-	r.GET("/audio/status/:chunk", getAudioStatusHandler)
+	r.POST("/upload", accessLogMiddleware(), uploadHandler)
+	r.POST("/upload/init", initUploadHandler)
+	r.PATCH("/upload/:uploadId/parts/:n", uploadPartHandler)
+	r.POST("/upload/:uploadId/complete", completeUploadHandler)
+	r.DELETE("/upload/:uploadId", abortUploadHandler)
+	r.POST("/keys", adminAuthMiddleware(), createKeyHandler)
+	r.GET("/audio/status/:chunk", hmacAuthMiddleware(), accessLogMiddleware(), getAudioStatusHandler)
 	r.GET("/status", statusHandler)
 	r.GET("/health", healthHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/test-audio", testAudioHandler)
 	r.GET("/start-next/:chunk", startNextChunkHandler)
+	r.GET("/audio/local/*path", localAudioHandler)
+	r.GET("/audio/:filename", hmacAuthMiddleware(), streamAudioHandler)
+	r.GET("/audio/download/:filename", hmacAuthMiddleware(), downloadFullAudioHandler)
+	r.GET("/audio/events", hmacAuthMiddleware(), audioEventsHandler)
 	r.GET("/settings", func(c *gin.Context) {
 		// Get settings from request header
 		settings := TTSSettings{
@@ -268,7 +339,7 @@ func main() {
 		}
 		c.JSON(http.StatusOK, settings)
 	})
-	r.POST("/generate-audio", func(c *gin.Context) {
+	r.POST("/generate-audio", hmacAuthMiddleware(), accessLogMiddleware(), func(c *gin.Context) {
 		var req struct {
 			Text     string      `json:"text"`
 			Settings TTSSettings `json:"settings"`
@@ -285,13 +356,8 @@ func main() {
 			req.Filename = fmt.Sprintf("generated_%d", time.Now().Unix())
 		}
 
-		options := map[string]string{
-			"model": req.Settings.Model,
-			"voice": req.Settings.Voice,
-			"filename": req.Filename,
-			"chunk": fmt.Sprintf("%d", req.Chunk),
-		}
-		audioData, err := generateAudio(req.Text, req.Settings, options)
+		options := ttsOptions(req.Settings, req.Filename, req.Chunk)
+		audioData, err := generateAudio(c.Request.Context(), req.Text, req.Settings, options)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -407,85 +473,11 @@ func extractTextFromPDF(filepath string) (string, error) {
 	return strings.TrimSpace(text.String()), nil
 }
 
-func chunkText(text string) []string {
-	// Clean up the text first
-	text = strings.TrimSpace(text)
-	text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
-
-	// Initialize the segmenter with English language
-	segmenter := sentencizer.NewSegmenter("en")
-
-	// Split text into sentences using Sentencizer
-	sentences := segmenter.Segment(text)
-
-	var allChunks []string
-	var currentChunk strings.Builder
-	wordCount := 0
-	const maxWordsPerChunk = 50 // Keep the same word limit for TTS optimization
-
-	for _, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence == "" {
-			continue
-		}
-
-		words := strings.Fields(sentence)
-		
-		// If a single sentence is longer than maxWordsPerChunk, split it
-		if len(words) > maxWordsPerChunk {
-			// First, add any existing chunk
-			if currentChunk.Len() > 0 {
-				chunk := strings.TrimSpace(currentChunk.String())
-				if chunk != "" {
-					allChunks = append(allChunks, chunk)
-				}
-				currentChunk.Reset()
-				wordCount = 0
-			}
-
-			// Then split the long sentence into chunks
-			for i := 0; i < len(words); i += maxWordsPerChunk {
-				end := i + maxWordsPerChunk
-				if end > len(words) {
-					end = len(words)
-				}
-				subChunk := strings.Join(words[i:end], " ")
-				// Only add ellipsis if this is not the end of the sentence
-				if end < len(words) {
-					subChunk += "..."
-				}
-				allChunks = append(allChunks, subChunk)
-			}
-			continue
-		}
-
-		// Start a new chunk if adding this sentence would exceed the word limit
-		if wordCount + len(words) > maxWordsPerChunk {
-			chunk := strings.TrimSpace(currentChunk.String())
-			if chunk != "" {
-				allChunks = append(allChunks, chunk)
-			}
-			currentChunk.Reset()
-			wordCount = 0
-		}
-
-		// Add the sentence to the current chunk
-		if wordCount > 0 {
-			currentChunk.WriteString(" ")
-		}
-		currentChunk.WriteString(sentence)
-		wordCount += len(words)
-	}
-
-	// Add any remaining text as a chunk
-	if currentChunk.Len() > 0 {
-		chunk := strings.TrimSpace(currentChunk.String())
-		if chunk != "" {
-			allChunks = append(allChunks, chunk)
-		}
-	}
-
-	return allChunks
+// chunkText splits text into TTS-ready chunks sized to provider's input
+// limit via tts.SplitForTTS, so a chunk never exceeds what the chosen
+// provider actually accepts per call.
+func chunkText(text string, provider string) []string {
+	return tts.SplitForTTS(text, tts.MaxRunesFor(provider))
 }
 
 func uploadHandler(c *gin.Context) {
@@ -529,18 +521,6 @@ func uploadHandler(c *gin.Context) {
 		}
 	}
 
-	text, err := extractTextFromPDF(filepath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract text from PDF"})
-		return
-	}
-
-	chunks := chunkText(text)
-	if len(chunks) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No text found in PDF"})
-		return
-	}
-
 	// Get TTS settings from headers
 	settings := TTSSettings{
 		Provider: c.GetHeader("X-TTS-Provider"),
@@ -548,58 +528,21 @@ func uploadHandler(c *gin.Context) {
 		Model:    c.GetHeader("X-TTS-Model"),
 		Voice:    c.GetHeader("X-TTS-Voice"),
 	}
-
-	// Validate required settings
 	if settings.Provider == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "TTS provider is required",
-		})
-		return
-	}
-
-	// Only require API key for non-fallback providers
-	if settings.Provider != "fallback" && settings.APIKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "API key is required for non-fallback providers",
-		})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "TTS provider is required"})
 		return
 	}
 
 	// Read pdfId from form (sent as a string)
 	pdfId := c.Request.FormValue("pdfId")
-	if pdfId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "pdfId is required"})
-		return
-	}
-
-	processor.pdfId = pdfId
 
-	audioID := processor.ProcessChunks(chunks, cleanFilename, settings)
-
-	for idx, text := range chunks {
-		_, err := db.Exec(context.Background(),
-			`INSERT INTO pdf_chunks (id, pdf_id, index, text, audio_url, created_at, updated_at)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-			cuid.New(), pdfId, idx, text, nil, time.Now(), time.Now(),
-		)
-		if err != nil {
-			log.Printf("Failed to insert chunk: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save chunks to DB"})
-			return
-		}
+	resp, err := processUploadedPDF(filepath, cleanFilename, pdfId, requestIDFromContext(c), settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, UploadResponse{
-		Message: "PDF processed successfully",
-		Chunks:  chunks,
-		AudioID: audioID,
-	})
-
-	// Schedule file cleanup after 24 hours
-	go func() {
-		time.Sleep(24 * time.Hour)
-		os.Remove(filepath)
-	}()
+	c.JSON(http.StatusOK, resp)
 }
 
 func getAudioStatusHandler(c *gin.Context) {
@@ -634,8 +577,15 @@ func getAudioStatusHandler(c *gin.Context) {
 	}
 
 	// Check if we have an audio file for this chunk
-	audioURL, exists := processor.audioFiles[chunkIndex]
-	if exists {
+	if _, exists := processor.audioFiles[chunkIndex]; exists {
+		audioURL, err := processor.presignedAudioURL(chunkIndex)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  fmt.Sprintf("failed to presign audio URL: %v", err),
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"status":    "ready",
 			"url":       audioURL,
@@ -675,6 +625,34 @@ func statusHandler(c *gin.Context) {
 	})
 }
 
+// localAudioHandler serves audio uploaded through the "fs" storage backend.
+// It's a no-op (404) when a cloud backend is active since those drivers
+// return their own public/presigned URLs instead. Every request must carry
+// the expires/signature query params FSBlob.PresignGet attaches, since this
+// route is the public link handed to a browser's audio player.
+func localAudioHandler(c *gin.Context) {
+	fsBlob, ok := processor.blob.(*storage.FSBlob)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "local audio serving is only available with STORAGE_BACKEND=fs"})
+		return
+	}
+
+	relKey := strings.TrimPrefix(c.Param("path"), "/")
+	if err := fsBlob.VerifySignedURL(relKey, c.Query("expires"), c.Query("signature")); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := fmt.Sprintf("audio/%s", relKey)
+	data, err := fsBlob.Get(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audio file not found"})
+		return
+	}
+
+	c.Data(http.StatusOK, "audio/mpeg", data)
+}
+
 func healthHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
@@ -709,7 +687,7 @@ func testAudioHandler(c *gin.Context) {
 		"filename": "test_audio",
 		"chunk": "1", // Start from chunk 1 for testing
 	}
-	audioData, err := generateAudio(testText, settings, options)
+	audioData, err := generateAudio(c.Request.Context(), testText, settings, options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("Test audio failed: %v", err)})
 		return
@@ -738,6 +716,7 @@ func (cp *ChunkProcessor) ProcessChunks(chunks []string, filename string, settin
 	cp.currentIdx = 0
 	cp.audioFiles = make(map[int]string)
 	cp.processing = make(map[int]bool)
+	cp.chunkSizes = make(map[int]int64)
 	cp.lastError = ""
 	cp.stopProcess = make(chan bool, 1)
 	cp.filename = filename
@@ -746,17 +725,47 @@ func (cp *ChunkProcessor) ProcessChunks(chunks []string, filename string, settin
 
 	audioID := cp.filename
 
-	// Start processing the first pair of chunks
-	go func() {
-		cp.generateTTS(0)
-		if len(cp.chunks) > 1 {
-			cp.generateTTS(1)
-		}
-	}()
+	cp.startGeneration()
 
 	return audioID
 }
 
+// startGeneration sizes cp.pool per cp.settings (falling back to the
+// GEN_WORKER_POOL_SIZE/GEN_LOOKAHEAD_DEPTH env defaults) and queues the
+// initial lookahead window starting at chunk 0.
+func (cp *ChunkProcessor) startGeneration() {
+	poolSize := cp.settings.WorkerPoolSize
+	if poolSize <= 0 {
+		poolSize = defaultWorkerPoolSize()
+	}
+	lookahead := cp.settings.LookaheadDepth
+	if lookahead <= 0 {
+		lookahead = defaultLookaheadDepth()
+	}
+
+	cp.pool.start(cp, poolSize)
+	cp.pool.enqueueRange(0, lookahead, len(cp.chunks))
+}
+
+// ttsOptions builds the per-call options map passed to a TTSProvider for
+// chunk index of filename, threading settings.Normalize/TargetLUFS through
+// so normalizedProvider can apply EBU R128 correction at generation time.
+func ttsOptions(settings TTSSettings, filename string, index int) map[string]string {
+	options := map[string]string{
+		"model":    settings.Model,
+		"voice":    settings.Voice,
+		"filename": filename,
+		"chunk":    fmt.Sprintf("%d", index),
+	}
+	if settings.Normalize != "" {
+		options["normalize"] = settings.Normalize
+		if settings.TargetLUFS != 0 {
+			options["target_lufs"] = strconv.FormatFloat(settings.TargetLUFS, 'f', -1, 64)
+		}
+	}
+	return options
+}
+
 func (cp *ChunkProcessor) generateTTS(index int) {
 	cp.mutex.Lock()
 	if index >= len(cp.chunks) {
@@ -769,22 +778,18 @@ func (cp *ChunkProcessor) generateTTS(index int) {
 	text := cp.chunks[index]
 	settings := cp.settings
 	pdfId := cp.pdfId
+	requestID := cp.requestID
 	cp.mutex.Unlock()
 
-	options := map[string]string{
-		"model": settings.Model,
-		"voice": settings.Voice,
-		"filename": cp.filename,
-		"chunk": fmt.Sprintf("%d", index),
-	}
+	options := ttsOptions(settings, cp.filename, index)
 
-	audioData, err := generateAudio(text, settings, options)
+	audioData, err := generateAudio(context.Background(), text, settings, options)
 	if err != nil {
 		cp.mutex.Lock()
-		cp.lastError = err.Error()
+		cp.lastError = fmt.Sprintf("[request_id=%s] %v", requestID, err)
 		delete(cp.processing, index)
 		cp.mutex.Unlock()
-		log.Printf("Error generating audio for chunk %d: %v", index, err)
+		log.Printf("Error generating audio for chunk %d (request_id=%s): %v", index, requestID, err)
 		return
 	}
 
@@ -793,15 +798,16 @@ func (cp *ChunkProcessor) generateTTS(index int) {
 	audioURL, err := cp.uploadToS3(audioData, expectedFileName)
 	if err != nil {
 		cp.mutex.Lock()
-		cp.lastError = fmt.Sprintf("failed to upload audio to S3: %v", err)
+		cp.lastError = fmt.Sprintf("[request_id=%s] failed to upload audio to S3: %v", requestID, err)
 		delete(cp.processing, index)
 		cp.mutex.Unlock()
-		log.Printf("Error uploading audio for chunk %d: %v", index, err)
+		log.Printf("Error uploading audio for chunk %d (request_id=%s): %v", index, requestID, err)
 		return
 	}
 
 	cp.mutex.Lock()
 	cp.audioFiles[index] = audioURL // Store the full S3 URL
+	cp.chunkSizes[index] = int64(len(audioData))
 	delete(cp.processing, index)
 	cp.mutex.Unlock()
 	log.Printf("Successfully generated and uploaded audio for chunk %d", index)
@@ -816,6 +822,20 @@ func (cp *ChunkProcessor) generateTTS(index int) {
 			log.Printf("Failed to update audio_url for chunk %d: %v", index, err)
 		}
 	}
+
+	cp.publish(pdfId, ChunkEvent{ChunkIndex: index, Status: "ready", URL: audioURL})
+
+	cp.mutex.RLock()
+	allDone := len(cp.audioFiles) == len(cp.chunks)
+	filename := cp.filename
+	cp.mutex.RUnlock()
+	if allDone {
+		cp.publish(pdfId, ChunkEvent{
+			ChunkIndex: -1,
+			Status:     "complete",
+			URL:        fmt.Sprintf("/audio/download/%s", filename),
+		})
+	}
 }
 
 func (cp *ChunkProcessor) callElevenLabsTTS(text string) ([]byte, error) {
@@ -910,21 +930,15 @@ func startNextChunkHandler(c *gin.Context) {
 
 	processor.mutex.Unlock()
 
-	// Start processing in a goroutine
-	go func() {
-		// First check if we need to generate the current chunk
-		if currentChunk >= 0 && currentChunk < len(processor.chunks) {
-			if _, exists := processor.audioFiles[currentChunk]; !exists && !processor.processing[currentChunk] {
-				processor.generateTTS(currentChunk)
-			}
-		}
-		// Then generate the next chunk
-		processor.generateTTS(nextChunk)
-		// Process the chunk after next if it exists
-		if nextChunk+1 < len(processor.chunks) {
-			processor.generateTTS(nextChunk + 1)
-		}
-	}()
+	// Queue currentChunk plus the lookahead window starting at nextChunk;
+	// the worker pool picks these up in order of proximity to currentIdx
+	// rather than us spawning a goroutine per request.
+	lookahead := processor.settings.LookaheadDepth
+	if lookahead <= 0 {
+		lookahead = defaultLookaheadDepth()
+	}
+	processor.pool.enqueue(currentChunk)
+	processor.pool.enqueueRange(nextChunk, lookahead, len(processor.chunks))
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Started processing chunks",
@@ -938,21 +952,16 @@ func min(a, b int) int {
 	return b
 }
 
-func generateAudio(text string, settings TTSSettings, options map[string]string) ([]byte, error) {
+func generateAudio(ctx context.Context, text string, settings TTSSettings, options map[string]string) ([]byte, error) {
 	provider := tts.NewTTSProvider(settings.Provider, settings.APIKey)
-	return provider.GenerateAudio(text, options)
+	return provider.GenerateAudio(ctx, text, options)
 }
 
 func (cp *ChunkProcessor) uploadToS3(audioData []byte, filename string) (string, error) {
 	// Validate inputs
-	if cp.bucketName == "" {
-		log.Printf("Error: S3 bucket name is empty")
-		return "", fmt.Errorf("S3 bucket name is not configured")
-	}
-
-	if cp.s3Client == nil {
-		log.Printf("Error: S3 client is not initialized")
-		return "", fmt.Errorf("S3 client is not initialized")
+	if cp.blob == nil {
+		log.Printf("Error: storage backend is not initialized")
+		return "", fmt.Errorf("storage backend is not initialized")
 	}
 
 	if len(audioData) == 0 {
@@ -960,42 +969,41 @@ func (cp *ChunkProcessor) uploadToS3(audioData []byte, filename string) (string,
 		return "", fmt.Errorf("no audio data to upload")
 	}
 
-	log.Printf("Uploading %d bytes to S3 bucket '%s' with key 'audio/%s'", len(audioData), cp.bucketName, filename)
+	key := fmt.Sprintf("audio/%s", filename)
+	log.Printf("Uploading %d bytes to storage backend '%s' with key '%s'", len(audioData), storageBackendName(), key)
 
-	input := &s3.PutObjectInput{
-		Bucket:      aws.String(cp.bucketName),
-		Key:         aws.String(fmt.Sprintf("audio/%s", filename)),
-		Body:        bytes.NewReader(audioData),
-		ContentType: aws.String("audio/mpeg"),
+	genInFlightUploads.Inc()
+	_, err := cp.blob.Put(context.Background(), key, audioData, "audio/mpeg", cp.settings.putOptions())
+	genInFlightUploads.Dec()
+	if err != nil {
+		log.Printf("Failed to upload to storage backend: %v", err)
+		return "", fmt.Errorf("failed to upload audio: %v", err)
 	}
 
-	// Log the actual values being used in the PutObject call
-	log.Printf("S3 PutObject Input - Bucket: %s, Key: audio/%s", *input.Bucket, filename)
-
-	_, err := cp.s3Client.PutObject(input)
+	ttl := presignTTL
+	if cp.settings.PresignTTLSeconds > 0 {
+		ttl = time.Duration(cp.settings.PresignTTLSeconds) * time.Second
+	}
+	url, err := cp.blob.PresignGet(context.Background(), key, int64(ttl.Seconds()))
 	if err != nil {
-		log.Printf("Failed to upload to S3: %v", err)
-		return "", fmt.Errorf("failed to upload to S3: %v", err)
+		log.Printf("Failed to presign uploaded audio file: %v", err)
+		return "", fmt.Errorf("failed to presign audio url: %v", err)
 	}
 
-	log.Printf("Successfully uploaded audio file to S3: %s", filename)
-
-	// Return the S3 URL
-	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/audio/%s", 
-		cp.bucketName,
-		*cp.s3Client.Config.Region,
-		filename), nil
+	log.Printf("Successfully uploaded audio file: %s", filename)
+	return url, nil
 }
 
-// Helper function to get bucket names
-func getBucketNames(buckets []*s3.Bucket) []string {
-	names := make([]string, len(buckets))
-	for i, bucket := range buckets {
-		if bucket.Name != nil {
-			names[i] = *bucket.Name
-		}
+// presignedAudioURL returns a short-lived signed URL for an already
+// generated chunk, rather than the permanent URL recorded in audioFiles.
+// Callers must hold at least cp.mutex.RLock().
+func (cp *ChunkProcessor) presignedAudioURL(chunkIndex int) (string, error) {
+	key := fmt.Sprintf("audio/%s_chunk_%d.mp3", cp.filename, chunkIndex)
+	ttl := presignTTL
+	if cp.settings.PresignTTLSeconds > 0 {
+		ttl = time.Duration(cp.settings.PresignTTLSeconds) * time.Second
 	}
-	return names
+	return cp.blob.PresignGet(context.Background(), key, int64(ttl.Seconds()))
 }
 
 // Add a new method to ChunkProcessor to accept chunkIDs
@@ -1005,6 +1013,7 @@ func (cp *ChunkProcessor) ProcessChunksWithIDs(chunks []string, filename string,
 	cp.currentIdx = 0
 	cp.audioFiles = make(map[int]string)
 	cp.processing = make(map[int]bool)
+	cp.chunkSizes = make(map[int]int64)
 	cp.lastError = ""
 	cp.stopProcess = make(chan bool, 1)
 	cp.filename = filename
@@ -1014,13 +1023,7 @@ func (cp *ChunkProcessor) ProcessChunksWithIDs(chunks []string, filename string,
 
 	audioID := cp.filename
 
-	// Start processing the first pair of chunks
-	go func() {
-		cp.generateTTS(0)
-		if len(cp.chunks) > 1 {
-			cp.generateTTS(1)
-		}
-	}()
+	cp.startGeneration()
 
 	return audioID
 } 
\ No newline at end of file
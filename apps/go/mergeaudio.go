@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/alexandergekov/open-reader/apps/go/tts"
+	"github.com/gin-gonic/gin"
+)
+
+// downloadFullAudioHandler merges every completed chunk for the book
+// currently loaded in the global processor into one MP3 via tts.MergeChunks
+// and streams it back, once all chunks have finished generating. Like
+// streamAudioHandler, it requires :filename and pdfId to match the book
+// actually loaded, so an HMAC key scoped to one pdfId can't download
+// whichever book happens to be resident in the processor singleton.
+func downloadFullAudioHandler(c *gin.Context) {
+	if !bookMatchesLoadedProcessor(c, c.Param("filename")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no book loaded"})
+		return
+	}
+
+	processor.mutex.RLock()
+	filename := processor.filename
+	total := len(processor.chunks)
+	done := len(processor.audioFiles)
+	processor.mutex.RUnlock()
+
+	if total == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no book loaded"})
+		return
+	}
+	if done < total {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("%d of %d chunks are still generating", total-done, total),
+		})
+		return
+	}
+
+	folder := "uploads/audio"
+	if err := cacheChunksLocally(folder, filename, total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := tts.MergeOptions{
+		Normalize: c.Query("normalize") == "ebu-r128",
+	}
+
+	outPath := path.Join(folder, fmt.Sprintf("%s_full.mp3", filename))
+	if err := tts.MergeChunks(c.Request.Context(), folder, filename, total, outPath, opts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to merge chunks: %v", err)})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.mp3"`, filename))
+	c.File(outPath)
+}
+
+// cacheChunksLocally ensures each chunk's MP3 exists under folder, pulling
+// it from the configured storage backend for providers (ElevenLabs,
+// Together, Replicate) that don't already write it there the way
+// HTGoTTSProvider and CartesiaTTSProvider do.
+func cacheChunksLocally(folder, filename string, total int) error {
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", folder, err)
+	}
+
+	for i := 0; i < total; i++ {
+		chunkPath := path.Join(folder, fmt.Sprintf("%s_chunk_%d.mp3", filename, i))
+		if _, err := os.Stat(chunkPath); err == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("audio/%s_chunk_%d.mp3", filename, i)
+		data, err := processor.blob.Get(context.Background(), key)
+		if err != nil {
+			return fmt.Errorf("failed to fetch chunk %d: %v", i, err)
+		}
+		if err := os.WriteFile(chunkPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to cache chunk %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
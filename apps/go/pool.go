@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	genQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "open_reader_generation_queue_depth",
+		Help: "Number of chunks waiting in the generation priority queue.",
+	})
+	genInFlightUploads = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "open_reader_inflight_uploads",
+		Help: "Number of chunk audio uploads currently in progress.",
+	})
+	genChunkLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "open_reader_chunk_generation_seconds",
+		Help:    "Time to generate and upload a single chunk's audio, start to finish.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// defaultWorkerPoolSize is how many chunks generate concurrently when
+// TTSSettings.WorkerPoolSize isn't set, overridable via GEN_WORKER_POOL_SIZE.
+func defaultWorkerPoolSize() int {
+	return envInt("GEN_WORKER_POOL_SIZE", 3)
+}
+
+// defaultLookaheadDepth is how many chunks past the one just requested get
+// queued alongside it, overridable via GEN_LOOKAHEAD_DEPTH.
+func defaultLookaheadDepth() int {
+	return envInt("GEN_LOOKAHEAD_DEPTH", 2)
+}
+
+func envInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+// genPool is a bounded worker pool that generates chunk audio in order of
+// proximity to ChunkProcessor.currentIdx, replacing the old pattern of
+// spawning one goroutine per "current + next + next+1" request. Callers
+// enqueue chunk indices with enqueue/enqueueRange; a fixed set of workers
+// pulls the closest pending index to currentIdx and calls generateTTS,
+// so lookahead depth is just however many indices get queued, not however
+// many goroutines happen to get spawned.
+type genPool struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending map[int]bool
+	spawned int
+}
+
+func newGenPool() *genPool {
+	p := &genPool{pending: make(map[int]bool)}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// start ensures at least size workers are running against cp, each pulling
+// chunks forever. It's safe to call repeatedly (e.g. once per book, with a
+// per-request WorkerPoolSize override) -- it only ever grows the pool, since
+// idle workers just block on an empty queue.
+func (p *genPool) start(cp *ChunkProcessor, size int) {
+	p.mu.Lock()
+	toSpawn := size - p.spawned
+	if toSpawn > 0 {
+		p.spawned += toSpawn
+	}
+	p.mu.Unlock()
+
+	for i := 0; i < toSpawn; i++ {
+		go p.worker(cp)
+	}
+}
+
+func (p *genPool) worker(cp *ChunkProcessor) {
+	for {
+		index := p.next(cp)
+
+		cp.mutex.RLock()
+		alreadyDone := false
+		if _, exists := cp.audioFiles[index]; exists {
+			alreadyDone = true
+		}
+		cp.mutex.RUnlock()
+		if alreadyDone {
+			continue
+		}
+
+		start := time.Now()
+		cp.generateTTS(index)
+		genChunkLatency.Observe(time.Since(start).Seconds())
+	}
+}
+
+// next blocks until a chunk index is pending, then returns whichever
+// pending index is closest to cp.currentIdx.
+func (p *genPool) next(cp *ChunkProcessor) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.pending) == 0 {
+		p.cond.Wait()
+	}
+
+	cp.mutex.RLock()
+	current := cp.currentIdx
+	cp.mutex.RUnlock()
+
+	best := -1
+	bestDist := 0
+	for idx := range p.pending {
+		dist := idx - current
+		if dist < 0 {
+			dist = -dist
+		}
+		if best == -1 || dist < bestDist {
+			best = idx
+			bestDist = dist
+		}
+	}
+
+	delete(p.pending, best)
+	genQueueDepth.Set(float64(len(p.pending)))
+	return best
+}
+
+// enqueue adds index to the pending set if it isn't already queued.
+func (p *genPool) enqueue(index int) {
+	p.mu.Lock()
+	if !p.pending[index] {
+		p.pending[index] = true
+		genQueueDepth.Set(float64(len(p.pending)))
+	}
+	p.cond.Signal()
+	p.mu.Unlock()
+}
+
+// enqueueRange queues [from, from+depth] (clamped to total-1).
+func (p *genPool) enqueueRange(from, depth, total int) {
+	for i := from; i <= from+depth && i < total; i++ {
+		if i >= 0 {
+			p.enqueue(i)
+		}
+	}
+}
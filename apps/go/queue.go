@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	sqsMessagesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "open_reader_sqs_messages_received_total",
+		Help: "SQS chunk messages received by RunWorker.",
+	})
+	sqsMessagesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "open_reader_sqs_messages_processed_total",
+		Help: "SQS chunk messages whose TTS generation and upload succeeded.",
+	})
+	sqsMessagesFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "open_reader_sqs_messages_failed_total",
+		Help: "SQS chunk messages that failed to enqueue, parse, generate, or upload.",
+	})
+)
+
+// ChunkMessage is one chunk's worth of TTS work, published by EnqueueChunks
+// and consumed by RunWorker. It carries everything a worker needs without
+// looking anything up: the source text, where the result belongs in
+// storage, and the settings to generate and upload it with.
+type ChunkMessage struct {
+	ChunkID   string      `json:"chunkId"`
+	PdfId     string      `json:"pdfId"`
+	Index     int         `json:"index"`
+	Text      string      `json:"text"`
+	Filename  string      `json:"filename"`
+	DestKey   string      `json:"destKey"`
+	Settings  TTSSettings `json:"settings"`
+	RequestID string      `json:"requestId"`
+}
+
+// newSQSClient builds an SQS client from AWS_REGION (same default as the S3
+// driver) and the standard AWS credential chain.
+func newSQSClient() (*sqs.SQS, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %v", err)
+	}
+	return sqs.New(sess), nil
+}
+
+// EnqueueChunks publishes one SQS message per chunk so a fleet of worker
+// processes (run with WORKER_MODE=1) can share TTS generation for large
+// documents instead of serializing it on the pod handling the upload.
+// Actual redelivery-to-DLQ behavior comes from the queue's own redrive
+// policy (maxReceiveCount); this just publishes and lets SQS own retries.
+func (cp *ChunkProcessor) EnqueueChunks(chunks []string, chunkIDs []string, filename string, settings TTSSettings) error {
+	if cp.sqsClient == nil {
+		return fmt.Errorf("SQS is not configured (set SQS_QUEUE_URL)")
+	}
+
+	// Mirror ProcessChunks' bookkeeping so the SSE/merge/range-streaming
+	// handlers -- which all key off cp.chunks/cp.filename -- see this book
+	// as loaded even though its audio is generated by separate worker pods.
+	cp.mutex.Lock()
+	cp.chunks = chunks
+	cp.currentIdx = 0
+	cp.audioFiles = make(map[int]string)
+	cp.processing = make(map[int]bool)
+	cp.chunkSizes = make(map[int]int64)
+	cp.lastError = ""
+	cp.filename = filename
+	cp.settings = settings
+	cp.mutex.Unlock()
+
+	for i, text := range chunks {
+		var chunkID string
+		if i < len(chunkIDs) {
+			chunkID = chunkIDs[i]
+		}
+
+		msg := ChunkMessage{
+			ChunkID:   chunkID,
+			PdfId:     cp.pdfId,
+			Index:     i,
+			Text:      text,
+			Filename:  filename,
+			DestKey:   fmt.Sprintf("audio/%s_chunk_%d.mp3", filename, i),
+			Settings:  settings,
+			RequestID: cp.requestID,
+		}
+
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chunk %d: %v", i, err)
+		}
+
+		if _, err := cp.sqsClient.SendMessage(&sqs.SendMessageInput{
+			QueueUrl:    aws.String(cp.sqsQueueURL),
+			MessageBody: aws.String(string(body)),
+		}); err != nil {
+			sqsMessagesFailed.Inc()
+			return fmt.Errorf("failed to enqueue chunk %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// sqsPollInterval is how long RunWorker sleeps after an empty long-poll or a
+// ReceiveMessage error, configurable via SQS_POLL_INTERVAL_SECONDS.
+func sqsPollInterval() time.Duration {
+	if v := os.Getenv("SQS_POLL_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return 5 * time.Second
+}
+
+// RunWorker long-polls cp.sqsQueueURL and processes chunk messages until ctx
+// is canceled. Run this in a process separate from the API so TTS compute
+// scales independently of request handling. A message is only deleted after
+// its audio is generated and uploaded; a message whose generation or upload
+// fails is left for SQS to redeliver, and the queue's redrive policy moves it
+// to the DLQ once ApproximateReceiveCount exceeds maxReceiveCount. A message
+// whose body cannot be parsed is deleted instead.
+func (cp *ChunkProcessor) RunWorker(ctx context.Context) {
+	if cp.sqsClient == nil {
+		log.Printf("RunWorker: SQS is not configured (set SQS_QUEUE_URL), exiting")
+		return
+	}
+
+	interval := sqsPollInterval()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		out, err := cp.sqsClient.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(cp.sqsQueueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(20), // long poll
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("RunWorker: failed to receive messages: %v", err)
+			time.Sleep(interval)
+			continue
+		}
+
+		for _, m := range out.Messages {
+			sqsMessagesReceived.Inc()
+			cp.processQueueMessage(ctx, m)
+		}
+
+		if len(out.Messages) == 0 {
+			time.Sleep(interval)
+		}
+	}
+}
+
+func (cp *ChunkProcessor) processQueueMessage(ctx context.Context, m *sqs.Message) {
+	var msg ChunkMessage
+	if err := json.Unmarshal([]byte(aws.StringValue(m.Body)), &msg); err != nil {
+		log.Printf("RunWorker: dropping unparseable message: %v", err)
+		sqsMessagesFailed.Inc()
+		cp.deleteMessage(m)
+		return
+	}
+
+	options := ttsOptions(msg.Settings, msg.Filename, msg.Index)
+
+	audioData, err := generateAudio(ctx, msg.Text, msg.Settings, options)
+	if err != nil {
+		log.Printf("RunWorker: TTS failed for chunk %d (request_id=%s): %v", msg.Index, msg.RequestID, err)
+		sqsMessagesFailed.Inc()
+		return
+	}
+
+	audioURL, err := cp.blob.Put(ctx, msg.DestKey, audioData, "audio/mpeg", msg.Settings.putOptions())
+	if err != nil {
+		log.Printf("RunWorker: upload failed for chunk %d (request_id=%s): %v", msg.Index, msg.RequestID, err)
+		sqsMessagesFailed.Inc()
+		return
+	}
+
+	cp.mutex.Lock()
+	cp.audioFiles[msg.Index] = audioURL
+	cp.chunkSizes[msg.Index] = int64(len(audioData))
+	pdfId := msg.PdfId
+	allDone := len(cp.audioFiles) == len(cp.chunks)
+	filename := cp.filename
+	cp.mutex.Unlock()
+
+	if db != nil {
+		_, err := db.Exec(ctx,
+			`UPDATE pdf_chunks SET audio_url = $1, updated_at = $2 WHERE pdf_id = $3 AND index = $4`,
+			audioURL, time.Now(), pdfId, msg.Index,
+		)
+		if err != nil {
+			log.Printf("RunWorker: failed to update audio_url for chunk %d: %v", msg.Index, err)
+		}
+	}
+
+	cp.publish(pdfId, ChunkEvent{ChunkIndex: msg.Index, Status: "ready", URL: audioURL})
+	if allDone {
+		cp.publish(pdfId, ChunkEvent{
+			ChunkIndex: -1,
+			Status:     "complete",
+			URL:        fmt.Sprintf("/audio/download/%s", filename),
+		})
+	}
+
+	cp.deleteMessage(m)
+	sqsMessagesProcessed.Inc()
+}
+
+func (cp *ChunkProcessor) deleteMessage(m *sqs.Message) {
+	_, err := cp.sqsClient.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(cp.sqsQueueURL),
+		ReceiptHandle: m.ReceiptHandle,
+	})
+	if err != nil {
+		log.Printf("RunWorker: failed to delete message: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChunkEvent is pushed to subscribers as a chunk finishes generating.
+type ChunkEvent struct {
+	ChunkIndex int    `json:"chunkIndex"`
+	Status     string `json:"status"`
+	URL        string `json:"url"`
+}
+
+// subscribe registers a new listener for pdfId and returns it along with an
+// unsubscribe func the caller must run (typically via defer) when done.
+func (cp *ChunkProcessor) subscribe(pdfId string) (chan ChunkEvent, func()) {
+	ch := make(chan ChunkEvent, 16)
+
+	cp.subMutex.Lock()
+	cp.subscribers[pdfId] = append(cp.subscribers[pdfId], ch)
+	cp.subMutex.Unlock()
+
+	unsubscribe := func() {
+		cp.subMutex.Lock()
+		defer cp.subMutex.Unlock()
+		subs := cp.subscribers[pdfId]
+		for i, existing := range subs {
+			if existing == ch {
+				cp.subscribers[pdfId] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// publish fans a ChunkEvent out to every current subscriber of pdfId. It
+// never blocks on a slow or dead subscriber.
+func (cp *ChunkProcessor) publish(pdfId string, event ChunkEvent) {
+	cp.subMutex.Lock()
+	defer cp.subMutex.Unlock()
+
+	for _, ch := range cp.subscribers[pdfId] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Dropping chunk event for pdfId %s: subscriber channel is full", pdfId)
+		}
+	}
+}
+
+// audioEventsHandler upgrades to Server-Sent Events and streams
+// {chunkIndex, status, url} as generateTTS finishes each chunk. A
+// reconnecting client sends "Last-Event-ID: <chunkIndex>" so it can replay
+// any chunks that finished while it was offline, backed by pdf_chunks.audio_url.
+func audioEventsHandler(c *gin.Context) {
+	pdfId := c.Query("pdfId")
+	if pdfId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pdfId is required"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ch, unsubscribe := processor.subscribe(pdfId)
+	defer unsubscribe()
+
+	for _, event := range missedChunkEvents(pdfId, c.GetHeader("Last-Event-ID")) {
+		writeChunkEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeChunkEvent(c, event)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeChunkEvent writes one SSE frame with an explicit id so a
+// reconnecting client can resume via Last-Event-ID.
+func writeChunkEvent(c *gin.Context, event ChunkEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal chunk event: %v", err)
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: message\ndata: %s\n\n", event.ChunkIndex, data)
+}
+
+// missedChunkEvents returns chunks that finished generating after
+// lastEventID, so a reconnecting client doesn't miss anything.
+func missedChunkEvents(pdfId, lastEventID string) []ChunkEvent {
+	if db == nil {
+		return nil
+	}
+
+	afterIdx := -1
+	if lastEventID != "" {
+		if parsed, err := strconv.Atoi(lastEventID); err == nil {
+			afterIdx = parsed
+		}
+	}
+
+	rows, err := db.Query(context.Background(),
+		`SELECT index, audio_url FROM pdf_chunks WHERE pdf_id = $1 AND index > $2 AND audio_url IS NOT NULL ORDER BY index`,
+		pdfId, afterIdx,
+	)
+	if err != nil {
+		log.Printf("Failed to replay chunk events for pdfId %s: %v", pdfId, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var events []ChunkEvent
+	for rows.Next() {
+		var index int
+		var audioURL string
+		if err := rows.Scan(&index, &audioURL); err != nil {
+			continue
+		}
+		events = append(events, ChunkEvent{ChunkIndex: index, Status: "ready", URL: audioURL})
+	}
+	return events
+}
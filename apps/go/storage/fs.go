@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FSBlob stores objects under a local directory and serves them back via
+// the app's own /audio/local/*path route, so the reader works end-to-end
+// without any cloud credentials.
+type FSBlob struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewFSBlob builds an FSBlob rooted at FS_STORAGE_DIR (default ./uploads,
+// so audio keys land under ./uploads/audio), publishing URLs under
+// FS_PUBLIC_BASE_URL (default http://localhost:8080/audio/local).
+func NewFSBlob() (*FSBlob, error) {
+	root := os.Getenv("FS_STORAGE_DIR")
+	if root == "" {
+		root = "./uploads"
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create FS storage dir %s: %v", root, err)
+	}
+
+	baseURL := os.Getenv("FS_PUBLIC_BASE_URL")
+	if baseURL == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL = fmt.Sprintf("http://localhost:%s/audio/local", port)
+	}
+
+	signingKey := os.Getenv("FS_URL_SIGNING_SECRET")
+	if signingKey == "" {
+		return nil, fmt.Errorf("FS_URL_SIGNING_SECRET must be set when using STORAGE_BACKEND=fs")
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve FS storage dir %s: %v", root, err)
+	}
+
+	return &FSBlob{root: absRoot, baseURL: baseURL, signingKey: []byte(signingKey)}, nil
+}
+
+// path resolves key to an absolute path under b.root, rejecting any key
+// that (e.g. via "../" segments) would resolve outside of it.
+func (b *FSBlob) path(key string) (string, error) {
+	dest := filepath.Join(b.root, filepath.FromSlash(key))
+	if dest != b.root && !strings.HasPrefix(dest, b.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("key %q escapes storage root", key)
+	}
+	return dest, nil
+}
+
+func (b *FSBlob) Put(ctx context.Context, key string, data []byte, contentType string, opts PutOptions) (string, error) {
+	// ACL/SSE/StorageClass/CacheControl/ContentDisposition/Metadata don't mean
+	// anything on a local directory; they're accepted for interface
+	// compatibility and ignored.
+	dest, err := b.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %v", key, err)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", key, err)
+	}
+	return fmt.Sprintf("%s/%s", b.baseURL, strings.TrimPrefix(key, "audio/")), nil
+}
+
+func (b *FSBlob) Get(ctx context.Context, key string) ([]byte, error) {
+	dest, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(dest)
+}
+
+func (b *FSBlob) GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	dest, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(dest)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, length)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (b *FSBlob) Delete(ctx context.Context, key string) error {
+	dest, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(dest)
+}
+
+// PresignGet wraps the proxy URL with an expiry and HMAC signature since a
+// local filesystem has no native presign concept. VerifySignedURL checks it
+// back out in the /audio/local handler.
+func (b *FSBlob) PresignGet(ctx context.Context, key string, ttlSeconds int64) (string, error) {
+	expires := time.Now().Add(time.Duration(ttlSeconds) * time.Second).Unix()
+	relKey := strings.TrimPrefix(key, "audio/")
+	sig := b.sign(relKey, expires)
+	return fmt.Sprintf("%s/%s?expires=%d&signature=%s", b.baseURL, relKey, expires, sig), nil
+}
+
+// VerifySignedURL checks the expires/signature query params a client
+// presents against relKey (the path segment after /audio/local/).
+func (b *FSBlob) VerifySignedURL(relKey string, expiresStr, signature string) error {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires parameter")
+	}
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("signed URL has expired")
+	}
+	expected := b.sign(relKey, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func (b *FSBlob) sign(relKey string, expires int64) string {
+	mac := hmac.New(sha256.New, b.signingKey)
+	mac.Write([]byte(fmt.Sprintf("%s:%d", relKey, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCSBlob stores objects in a Google Cloud Storage bucket.
+type GCSBlob struct {
+	client     *storage.Client
+	bucketName string
+}
+
+// NewGCSBlob builds a GCSBlob from GCS_BUCKET. Credentials are resolved the
+// standard way (GOOGLE_APPLICATION_CREDENTIALS or workload identity).
+func NewGCSBlob() (*GCSBlob, error) {
+	bucketName := os.Getenv("GCS_BUCKET")
+	if bucketName == "" {
+		return nil, fmt.Errorf("GCS_BUCKET environment variable is required")
+	}
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %v", err)
+	}
+
+	return &GCSBlob{client: client, bucketName: bucketName}, nil
+}
+
+func (b *GCSBlob) Put(ctx context.Context, key string, data []byte, contentType string, opts PutOptions) (string, error) {
+	w := b.client.Bucket(b.bucketName).Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if opts.CacheControl != "" {
+		w.CacheControl = opts.CacheControl
+	}
+	if opts.ContentDisposition != "" {
+		w.ContentDisposition = opts.ContentDisposition
+	}
+	if len(opts.Metadata) > 0 {
+		w.Metadata = opts.Metadata
+	}
+	switch opts.StorageClass {
+	case "STANDARD_IA":
+		w.StorageClass = "NEARLINE"
+	case "ONEZONE_IA":
+		w.StorageClass = "DURABLE_REDUCED_AVAILABILITY"
+	}
+	// GCS has no per-object ACL/SSE-C equivalent of opts.ACL/ServerSideEncryption
+	// worth mapping here: bucket IAM governs access, and encryption-at-rest is
+	// always on.
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return "", fmt.Errorf("failed to upload to GCS: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize GCS upload: %v", err)
+	}
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", b.bucketName, key), nil
+}
+
+func (b *GCSBlob) Get(ctx context.Context, key string) ([]byte, error) {
+	r, err := b.client.Bucket(b.bucketName).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from GCS: %v", key, err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (b *GCSBlob) GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	r, err := b.client.Bucket(b.bucketName).Object(key).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get range of %s from GCS: %v", key, err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (b *GCSBlob) Delete(ctx context.Context, key string) error {
+	return b.client.Bucket(b.bucketName).Object(key).Delete(ctx)
+}
+
+func (b *GCSBlob) PresignGet(ctx context.Context, key string, ttlSeconds int64) (string, error) {
+	return b.client.Bucket(b.bucketName).SignedURL(key, &storage.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(secondsToDuration(ttlSeconds)),
+	})
+}
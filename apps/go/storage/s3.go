@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Blob stores objects in an AWS S3 bucket. This is the original behavior
+// the app shipped with before the storage interface existed.
+type S3Blob struct {
+	client      *s3.S3
+	bucketName  string
+	region      string
+	partSize    int64
+	concurrency int
+}
+
+// NewS3Blob builds an S3Blob from AWS_REGION, AWS_S3_BUCKET, AWS_ACCESS_KEY,
+// and AWS_SECRET_ACCESS_KEY, verifying bucket access up front. Setting
+// S3_ENDPOINT points the same driver at an S3-compatible store instead of
+// AWS (MinIO, Ceph RGW, DigitalOcean Spaces, ...); S3_FORCE_PATH_STYLE=1
+// and S3_DISABLE_SSL=1 cover the two knobs those deployments usually need.
+func NewS3Blob() (*S3Blob, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	bucketName := os.Getenv("AWS_S3_BUCKET")
+	if bucketName == "" {
+		return nil, fmt.Errorf("AWS_S3_BUCKET environment variable is required")
+	}
+
+	config := &aws.Config{
+		Region: aws.String(region),
+		Credentials: credentials.NewStaticCredentials(
+			os.Getenv("AWS_ACCESS_KEY"),
+			os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			"",
+		),
+	}
+
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+		config.S3ForcePathStyle = aws.Bool(os.Getenv("S3_FORCE_PATH_STYLE") == "1")
+		config.DisableSSL = aws.Bool(os.Getenv("S3_DISABLE_SSL") == "1")
+	}
+
+	sess := session.Must(session.NewSession(config))
+
+	client := s3.New(sess)
+	if _, err := client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(bucketName)}); err != nil {
+		return nil, fmt.Errorf("failed to access S3 bucket %s: %v", bucketName, err)
+	}
+
+	return &S3Blob{
+		client:      client,
+		bucketName:  bucketName,
+		region:      region,
+		partSize:    partSizeFromEnv(),
+		concurrency: concurrencyFromEnv(),
+	}, nil
+}
+
+// partSizeFromEnv reads S3_UPLOAD_PART_SIZE_MB, defaulting to the
+// s3manager minimum of 5MB so long single-chunk audio (multi-MB from
+// providers like ElevenLabs) uploads in parallel parts instead of one shot.
+func partSizeFromEnv() int64 {
+	mb := 5
+	if v := os.Getenv("S3_UPLOAD_PART_SIZE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			mb = n
+		}
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// concurrencyFromEnv reads S3_UPLOAD_CONCURRENCY, defaulting to the
+// s3manager default of 5 parts in flight at once.
+func concurrencyFromEnv() int {
+	if v := os.Getenv("S3_UPLOAD_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 5
+}
+
+func (b *S3Blob) Put(ctx context.Context, key string, data []byte, contentType string, opts PutOptions) (string, error) {
+	acl := opts.ACL
+	if acl == "" {
+		acl = "private"
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket:      aws.String(b.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String(acl),
+	}
+	if opts.ServerSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(opts.ServerSideEncryption)
+	}
+	if opts.SSECustomerKey != "" {
+		input.SSECustomerKey = aws.String(opts.SSECustomerKey)
+		input.SSECustomerKeyMD5 = aws.String(opts.SSECustomerKeyMD5)
+	}
+	if opts.StorageClass != "" {
+		input.StorageClass = aws.String(opts.StorageClass)
+	}
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
+	if len(opts.Metadata) > 0 {
+		input.Metadata = aws.StringMap(opts.Metadata)
+	}
+
+	uploader := s3manager.NewUploaderWithClient(b.client, func(u *s3manager.Uploader) {
+		u.PartSize = b.partSize
+		u.Concurrency = b.concurrency
+	})
+	if _, err := uploader.UploadWithContext(ctx, input); err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %v", err)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", b.bucketName, b.region, key), nil
+}
+
+func (b *S3Blob) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from S3: %v", key, err)
+	}
+	defer out.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(out.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *S3Blob) GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	rangeHeader := fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(rangeHeader),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get range %s of %s from S3: %v", rangeHeader, key, err)
+	}
+	defer out.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(out.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *S3Blob) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucketName),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (b *S3Blob) PresignGet(ctx context.Context, key string, ttlSeconds int64) (string, error) {
+	req, _ := b.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(b.bucketName),
+		Key:    aws.String(key),
+	})
+	return req.Presign(secondsToDuration(ttlSeconds))
+}
@@ -0,0 +1,71 @@
+// Package storage abstracts the object-storage backend used for generated
+// audio so the reader can run against AWS S3, Aliyun OSS, Google Cloud
+// Storage, or a plain local directory without the rest of the app caring
+// which one is active.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PutOptions carries the durability/cost/security knobs a caller may want to
+// set on an object at upload time. It mirrors the AWS SDK's PutObjectInput
+// since S3 is the backend with the richest support; drivers that can't honor
+// a given field (FS, and parts of OSS/GCS) just ignore it.
+type PutOptions struct {
+	// ACL is the S3 canned ACL (e.g. "private", "public-read"). Defaults to
+	// "private" when empty so buckets stay secure unless a caller opts in.
+	ACL string
+	// ServerSideEncryption selects SSE-S3/SSE-KMS (e.g. "AES256", "aws:kms").
+	ServerSideEncryption string
+	// SSECustomerKey and SSECustomerKeyMD5 configure SSE-C (customer-supplied
+	// key). Both must be set together.
+	SSECustomerKey    string
+	SSECustomerKeyMD5 string
+	// StorageClass is the S3 storage class (e.g. "STANDARD_IA", "ONEZONE_IA").
+	StorageClass       string
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
+}
+
+// Blob is the contract every storage driver implements. Keys are always
+// forward-slash paths relative to the driver's root (e.g. "audio/book_chunk_0.mp3").
+type Blob interface {
+	Put(ctx context.Context, key string, data []byte, contentType string, opts PutOptions) (url string, err error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetRange fetches length bytes starting at offset without downloading
+	// the whole object, so seek/scrub over a long audio file stays cheap.
+	GetRange(ctx context.Context, key string, offset, length int64) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	PresignGet(ctx context.Context, key string, ttl int64) (string, error)
+}
+
+// New selects a driver based on STORAGE_BACKEND (s3|oss|gcs|fs, default s3)
+// and configures it from the environment.
+func New() (Blob, error) {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "s3"
+	}
+
+	switch backend {
+	case "s3":
+		return NewS3Blob()
+	case "oss":
+		return NewOSSBlob()
+	case "gcs":
+		return NewGCSBlob()
+	case "fs":
+		return NewFSBlob()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (want s3, oss, gcs, or fs)", backend)
+	}
+}
+
+func secondsToDuration(seconds int64) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chunkSpan is one entry in the byte-offset index: it covers global offsets
+// [start, start+size) and maps back to the storage key for chunkIdx.
+type chunkSpan struct {
+	chunkIdx int
+	start    int64
+	size     int64
+}
+
+// chunkSpans builds the global-offset -> (chunkIdx, intra-chunk offset)
+// index from the chunks that have finished uploading so far, in chunk
+// order. It stops at the first chunk that isn't ready yet, so the streamed
+// range always covers a contiguous prefix of the book rather than leaving
+// gaps for chunks still being generated.
+func (cp *ChunkProcessor) chunkSpans() []chunkSpan {
+	cp.mutex.RLock()
+	defer cp.mutex.RUnlock()
+
+	spans := make([]chunkSpan, 0, len(cp.chunkSizes))
+	var offset int64
+	for i := 0; i < len(cp.chunks); i++ {
+		size, ok := cp.chunkSizes[i]
+		if !ok {
+			break
+		}
+		spans = append(spans, chunkSpan{chunkIdx: i, start: offset, size: size})
+		offset += size
+	}
+	return spans
+}
+
+// bookMatchesLoadedProcessor reports whether filename and the request's
+// pdfId query param both match the book currently loaded in the global
+// processor singleton. Both are required: the singleton only ever tracks
+// one book at a time, so this is what keeps a request for one pdfId from
+// being served whichever other book happens to be loaded.
+func bookMatchesLoadedProcessor(c *gin.Context, filename string) bool {
+	pdfId := c.Query("pdfId")
+	if filename == "" || pdfId == "" {
+		return false
+	}
+
+	processor.mutex.RLock()
+	defer processor.mutex.RUnlock()
+	return filename == processor.filename && pdfId == processor.pdfId
+}
+
+// streamAudioHandler serves the concatenation of all completed chunks for
+// the book currently loaded in the global processor as one seekable MP3.
+// A Range request is translated into one or more GetRange fetches against
+// only the chunk objects it overlaps, so scrubbing a long book never
+// downloads a whole chunk -- let alone the whole book -- just to serve a
+// few seconds of audio.
+//
+// The request must name the book it wants via :filename and pdfId, and
+// both must match what's actually loaded in processor -- otherwise an HMAC
+// key scoped to one pdfId could stream whatever book happens to be loaded
+// for someone else. hmacAuthMiddleware has already checked the key is
+// scoped to the pdfId given here; this just makes sure that pdfId is for
+// real, rather than a query param nobody reads.
+func streamAudioHandler(c *gin.Context) {
+	if !bookMatchesLoadedProcessor(c, c.Param("filename")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no audio available yet"})
+		return
+	}
+
+	spans := processor.chunkSpans()
+	if len(spans) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no audio available yet"})
+		return
+	}
+
+	last := spans[len(spans)-1]
+	total := last.start + last.size
+
+	c.Header("Accept-Ranges", "bytes")
+
+	start, end := int64(0), total-1
+	status := http.StatusOK
+	if rangeHeader := c.GetHeader("Range"); rangeHeader != "" {
+		var err error
+		start, end, err = parseRangeHeader(rangeHeader, total)
+		if err != nil {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", total))
+			c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": err.Error()})
+			return
+		}
+		status = http.StatusPartialContent
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, total))
+	}
+
+	processor.mutex.RLock()
+	filename := processor.filename
+	processor.mutex.RUnlock()
+
+	c.Header("Content-Type", "audio/mpeg")
+	c.Header("Content-Length", fmt.Sprintf("%d", end-start+1))
+	c.Status(status)
+
+	ctx := c.Request.Context()
+	for _, span := range spans {
+		spanEnd := span.start + span.size - 1
+		if spanEnd < start || span.start > end {
+			continue
+		}
+
+		fetchStart := maxInt64(start, span.start) - span.start
+		fetchEnd := minInt64(end, spanEnd) - span.start
+
+		key := fmt.Sprintf("audio/%s_chunk_%d.mp3", filename, span.chunkIdx)
+		data, err := processor.blob.GetRange(ctx, key, fetchStart, fetchEnd-fetchStart+1)
+		if err != nil {
+			log.Printf("streamAudioHandler: failed to fetch range of chunk %d: %v", span.chunkIdx, err)
+			return
+		}
+		if _, err := c.Writer.Write(data); err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// parseRangeHeader parses a single-range "bytes=" Range header value against
+// total, supporting the start-end, start-, and -suffixLength forms. Multiple
+// ranges (comma-separated) aren't supported since the response is a single
+// contiguous stream.
+func parseRangeHeader(header string, total int64) (start, end int64, err error) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, 0, fmt.Errorf("unsupported range unit")
+	}
+	spec := strings.TrimPrefix(header, "bytes=")
+	if strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("multiple ranges are not supported")
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("malformed range")
+	}
+
+	if parts[0] == "" {
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("malformed range")
+		}
+		if n > total {
+			n = total
+		}
+		return total - n, total - 1, nil
+	}
+
+	start, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed range")
+	}
+	if parts[1] == "" {
+		end = total - 1
+	} else if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed range")
+	}
+
+	if start > end || start < 0 || end >= total {
+		return 0, 0, fmt.Errorf("range not satisfiable")
+	}
+	return start, end, nil
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,152 @@
+package tts
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Cache stores generated audio bytes keyed by a content hash of the
+// provider/model/voice/text/format tuple, so re-rendering text that has
+// already been rendered (the same PDF reprocessed, or a paragraph shared
+// across two documents) reuses bytes instead of re-billing the provider.
+// Eviction is least-recently-used once the cache exceeds maxBytes.
+type Cache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// NewCache creates a Cache that evicts least-recently-used entries once
+// the total cached audio exceeds maxBytes. maxBytes <= 0 means unbounded.
+func NewCache(maxBytes int64) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+var cacheWhitespaceRe = regexp.MustCompile(`\s+`)
+
+// normalizeTextForCache collapses runs of whitespace so cosmetic
+// differences in how text was split into chunks don't produce distinct
+// cache keys for what is otherwise the same sentence.
+func normalizeTextForCache(text string) string {
+	return strings.TrimSpace(cacheWhitespaceRe.ReplaceAllString(text, " "))
+}
+
+// HashKey computes a stable sha256 key over arbitrary byte parts, joined
+// by a NUL separator. Unlike CacheKey it does no whitespace normalization,
+// so it's the right choice for keys over binary data (e.g. normalizing
+// already-rendered audio) rather than provider text input.
+func HashKey(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheKey computes the content-addressed key for a GenerateAudio call.
+// filename/chunk are intentionally excluded: they only name the on-disk
+// alias for a chunk and don't affect the rendered audio, so the key stays
+// stable across renames.
+func CacheKey(provider, model, voice, text, outputFormat string) string {
+	normalized := normalizeTextForCache(text)
+	h := sha256.Sum256([]byte(provider + "\x00" + model + "\x00" + voice + "\x00" + normalized + "\x00" + outputFormat))
+	return hex.EncodeToString(h[:])
+}
+
+// Get returns the cached bytes for key, marking it most-recently-used.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+// Put stores data under key, evicting least-recently-used entries as
+// needed to stay within maxBytes.
+func (c *Cache) Put(key string, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.size -= int64(len(el.Value.(*cacheEntry).data))
+		el.Value.(*cacheEntry).data = data
+		c.size += int64(len(data))
+		c.order.MoveToFront(el)
+	} else {
+		el := c.order.PushFront(&cacheEntry{key: key, data: data})
+		c.entries[key] = el
+		c.size += int64(len(data))
+	}
+
+	for c.maxBytes > 0 && c.size > c.maxBytes && c.order.Len() > 0 {
+		back := c.order.Back()
+		entry := back.Value.(*cacheEntry)
+		c.size -= int64(len(entry.data))
+		c.order.Remove(back)
+		delete(c.entries, entry.key)
+	}
+}
+
+// cachedProvider wraps a TTSProvider with a Cache, transparently reusing
+// bytes for text already rendered with the same provider/model/voice/format
+// combination instead of hitting the underlying provider's network API.
+type cachedProvider struct {
+	name     string
+	provider TTSProvider
+	cache    *Cache
+}
+
+// WithCache wraps provider so GenerateAudio is served from cache on a hit
+// and populates cache on a successful call. name identifies the provider
+// in the cache key (it does not have to match a NewTTSProvider name, but
+// should be stable across calls for the same underlying provider).
+func WithCache(name string, provider TTSProvider, cache *Cache) TTSProvider {
+	return &cachedProvider{name: name, provider: provider, cache: cache}
+}
+
+func (c *cachedProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	outputFormat := options["output_format"]
+	if outputFormat == "" {
+		outputFormat = "mp3"
+	}
+	if options["normalize"] != "" {
+		// Normalized and un-normalized renders of the same text must not
+		// collide in the cache.
+		outputFormat += ":normalize=" + options["normalize"]
+	}
+	key := CacheKey(c.name, options["model"], options["voice"], text, outputFormat)
+
+	if data, ok := c.cache.Get(key); ok {
+		return data, nil
+	}
+
+	data, err := c.provider.GenerateAudio(ctx, text, options)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.Put(key, data)
+	return data, nil
+}
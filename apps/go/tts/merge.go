@@ -0,0 +1,164 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// MergeOptions controls optional post-processing MergeChunks applies to
+// each chunk before concatenating it into the merged file.
+type MergeOptions struct {
+	// Normalize EBU R128-normalizes every chunk to TargetLUFS before
+	// concatenation, so volume doesn't jump at chunk boundaries when
+	// chunks came from different providers (or the same provider at
+	// different times).
+	Normalize bool
+	// TargetLUFS overrides DefaultTargetLUFS when Normalize is set.
+	TargetLUFS float64
+}
+
+// MergeChunks concatenates the ordered chunk MP3s <filename>_chunk_0.mp3 ..
+// <filename>_chunk_{numChunks-1}.mp3 in folder into one continuous MP3 at
+// outPath, suitable for offline listening. The inputs are constant-bitrate
+// MP3 (128kbps/44.1kHz, as emitted by ElevenLabs/Cartesia), so a correct
+// merge means stripping each file's ID3v2 header and any ID3v1 trailer and
+// concatenating the raw frames: a decoder treats back-to-back MPEG audio
+// frames as one continuous stream, but a tag sitting between two chunks
+// would not.
+func MergeChunks(ctx context.Context, folder, filename string, numChunks int, outPath string, opts MergeOptions) error {
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", outPath, err)
+	}
+	defer out.Close()
+
+	if err := writeID3v2Tag(out, filename, numChunks); err != nil {
+		return fmt.Errorf("failed to write ID3 tag: %v", err)
+	}
+
+	for i := 0; i < numChunks; i++ {
+		chunkPath := path.Join(folder, fmt.Sprintf("%s_chunk_%d.mp3", filename, i))
+		data, err := os.ReadFile(chunkPath)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %d: %v", i, err)
+		}
+
+		if opts.Normalize {
+			data, err = normalizeForMerge(ctx, data, opts.TargetLUFS)
+			if err != nil {
+				return fmt.Errorf("failed to normalize chunk %d: %v", i, err)
+			}
+		}
+
+		frames, err := mpegFrames(data)
+		if err != nil {
+			return fmt.Errorf("chunk %d: %v", i, err)
+		}
+
+		if _, err := out.Write(frames); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeForMerge normalizes data to targetLUFS (DefaultTargetLUFS if
+// unset), reusing the shared audio cache so a chunk already normalized in
+// a previous merge of the same book is never re-measured.
+func normalizeForMerge(ctx context.Context, data []byte, targetLUFS float64) ([]byte, error) {
+	if targetLUFS == 0 {
+		targetLUFS = DefaultTargetLUFS
+	}
+	key := HashKey([]byte(fmt.Sprintf("%.1f", targetLUFS)), data)
+	return NormalizeCached(ctx, EBUR128Normalizer{}, sharedCache(), key, data, targetLUFS)
+}
+
+// mpegFrames strips any leading ID3v2 header and trailing ID3v1 tag from
+// data and returns the raw MPEG audio frames in between, starting at the
+// first valid frame sync word (the top 11 bits all set, covering
+// 0xFFFB/0xFFF3/0xFFFA and the other MPEG1/2 Layer III variants).
+func mpegFrames(data []byte) ([]byte, error) {
+	start := 0
+	if len(data) >= 10 && bytes.Equal(data[0:3], []byte("ID3")) {
+		size := synchsafeToInt(data[6], data[7], data[8], data[9])
+		start = 10 + size
+		if start > len(data) {
+			start = len(data)
+		}
+	}
+
+	end := len(data)
+	if end-start >= 128 && bytes.Equal(data[end-128:end-125], []byte("TAG")) {
+		end -= 128
+	}
+
+	syncIdx := -1
+	for i := start; i+1 < end; i++ {
+		if data[i] == 0xFF && data[i+1]&0xE0 == 0xE0 {
+			syncIdx = i
+			break
+		}
+	}
+	if syncIdx == -1 {
+		return nil, fmt.Errorf("no MPEG frame sync word found")
+	}
+
+	return data[syncIdx:end], nil
+}
+
+func synchsafeToInt(b0, b1, b2, b3 byte) int {
+	return int(b0)<<21 | int(b1)<<14 | int(b2)<<7 | int(b3)
+}
+
+// writeID3v2Tag writes a minimal ID3v2.3 tag with a title (TIT2) and track
+// count (TRCK) frame to w, ahead of the merged audio frames.
+func writeID3v2Tag(w io.Writer, title string, trackCount int) error {
+	var frames bytes.Buffer
+	writeID3v23Frame(&frames, "TIT2", title)
+	writeID3v23Frame(&frames, "TRCK", fmt.Sprintf("1/%d", trackCount))
+
+	header := make([]byte, 10)
+	copy(header[0:3], "ID3")
+	header[3] = 3 // version 2.3
+	header[4] = 0 // revision
+	header[5] = 0 // flags
+	putSynchsafe(header[6:10], frames.Len())
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(frames.Bytes())
+	return err
+}
+
+// writeID3v23Frame appends one ID3v2.3 text frame (ISO-8859-1 encoding,
+// encoding byte 0x00) to buf.
+func writeID3v23Frame(buf *bytes.Buffer, id, value string) {
+	body := append([]byte{0x00}, []byte(value)...)
+
+	header := make([]byte, 10)
+	copy(header[0:4], id)
+	putUint32BE(header[4:8], uint32(len(body)))
+
+	buf.Write(header)
+	buf.Write(body)
+}
+
+func putSynchsafe(dst []byte, n int) {
+	dst[0] = byte((n >> 21) & 0x7F)
+	dst[1] = byte((n >> 14) & 0x7F)
+	dst[2] = byte((n >> 7) & 0x7F)
+	dst[3] = byte(n & 0x7F)
+}
+
+func putUint32BE(dst []byte, n uint32) {
+	dst[0] = byte(n >> 24)
+	dst[1] = byte(n >> 16)
+	dst[2] = byte(n >> 8)
+	dst[3] = byte(n)
+}
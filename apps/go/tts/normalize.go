@@ -0,0 +1,154 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// DefaultTargetLUFS is the EBU R128 integrated loudness target for spoken
+// audio -- quieter than music mastering targets, since dialogue has no
+// dynamic headroom to spare.
+const DefaultTargetLUFS = -16.0
+
+// Normalizer measures and corrects the loudness of an MP3 so chunks
+// rendered by different providers (or the same provider at different
+// times) land at the same perceived volume once concatenated.
+type Normalizer interface {
+	Normalize(ctx context.Context, mp3 []byte, targetLUFS float64) ([]byte, error)
+}
+
+// EBUR128Normalizer is the default Normalizer, backed by ffmpeg's two-pass
+// loudnorm filter: one pass measures integrated loudness, true peak, and
+// loudness range, and a second pass applies the correction those
+// measurements imply.
+type EBUR128Normalizer struct{}
+
+type loudnormMeasurement struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// Normalize implements Normalizer via ffmpeg's loudnorm filter.
+func (EBUR128Normalizer) Normalize(ctx context.Context, mp3 []byte, targetLUFS float64) ([]byte, error) {
+	measurement, err := measureLoudness(ctx, mp3, targetLUFS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure loudness: %v", err)
+	}
+	return applyLoudnorm(ctx, mp3, targetLUFS, measurement)
+}
+
+func measureLoudness(ctx context.Context, mp3 []byte, targetLUFS float64) (*loudnormMeasurement, error) {
+	filter := fmt.Sprintf("loudnorm=I=%.1f:TP=-1.5:LRA=11:print_format=json", targetLUFS)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "info",
+		"-f", "mp3", "-i", "pipe:0",
+		"-af", filter,
+		"-f", "null", "-",
+	)
+	cmd.Stdin = bytes.NewReader(mp3)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg measure pass failed: %v (%s)", err, stderr.String())
+	}
+
+	// loudnorm writes its JSON measurement as the last brace-delimited
+	// block on stderr, after its regular progress logging.
+	jsonStart := bytes.LastIndexByte(stderr.Bytes(), '{')
+	if jsonStart == -1 {
+		return nil, fmt.Errorf("no loudnorm measurement found in ffmpeg output")
+	}
+
+	var m loudnormMeasurement
+	if err := json.Unmarshal(stderr.Bytes()[jsonStart:], &m); err != nil {
+		return nil, fmt.Errorf("failed to parse loudnorm measurement: %v", err)
+	}
+	return &m, nil
+}
+
+func applyLoudnorm(ctx context.Context, mp3 []byte, targetLUFS float64, m *loudnormMeasurement) ([]byte, error) {
+	filter := fmt.Sprintf(
+		"loudnorm=I=%.1f:TP=-1.5:LRA=11:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+		targetLUFS, m.InputI, m.InputTP, m.InputLRA, m.InputThresh, m.TargetOffset,
+	)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "error",
+		"-f", "mp3", "-i", "pipe:0",
+		"-af", filter,
+		"-codec:a", "libmp3lame", "-b:a", "128k",
+		"-f", "mp3", "pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(mp3)
+
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg normalize pass failed: %v (%s)", err, stderr.String())
+	}
+
+	return out.Bytes(), nil
+}
+
+// NormalizeCached normalizes mp3 to targetLUFS via n, reusing a
+// previously normalized result under cacheKey so repeat normalization
+// (e.g. re-merging the same book) is free.
+func NormalizeCached(ctx context.Context, n Normalizer, cache *Cache, cacheKey string, mp3 []byte, targetLUFS float64) ([]byte, error) {
+	key := cacheKey + ":normalized:" + strconv.FormatFloat(targetLUFS, 'f', 1, 64)
+	if data, ok := cache.Get(key); ok {
+		return data, nil
+	}
+
+	data, err := n.Normalize(ctx, mp3, targetLUFS)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Put(key, data)
+	return data, nil
+}
+
+// normalizedProvider wraps a TTSProvider, normalizing its output to a
+// target loudness when the caller opts in via options["normalize"].
+type normalizedProvider struct {
+	provider   TTSProvider
+	normalizer Normalizer
+}
+
+// WithNormalization wraps provider so a caller can opt into loudness
+// normalization per call via options["normalize"] = "ebu-r128" (the only
+// supported value today) and options["target_lufs"] (defaults to
+// DefaultTargetLUFS). Calls that don't opt in pay nothing extra.
+func WithNormalization(provider TTSProvider, normalizer Normalizer) TTSProvider {
+	return &normalizedProvider{provider: provider, normalizer: normalizer}
+}
+
+func (p *normalizedProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	data, err := p.provider.GenerateAudio(ctx, text, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options["normalize"] != "ebu-r128" {
+		return data, nil
+	}
+
+	target := DefaultTargetLUFS
+	if v := options["target_lufs"]; v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			target = f
+		}
+	}
+
+	return p.normalizer.Normalize(ctx, data, target)
+}
@@ -0,0 +1,128 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PiperProvider implements TTSProvider by exec'ing the piper binary
+// (https://github.com/rhasspy/piper) locally, giving users without an API
+// key a fully offline, high-quality TTS path with no rate limits.
+type PiperProvider struct {
+	voiceDir string
+}
+
+// NewPiperProvider creates a PiperProvider that looks up voice models
+// (<name>.onnx + <name>.onnx.json pairs) under voiceDir.
+func NewPiperProvider(voiceDir string) *PiperProvider {
+	return &PiperProvider{voiceDir: voiceDir}
+}
+
+// piperVoiceDir resolves PIPER_VOICE_DIR, defaulting to ./voices.
+func piperVoiceDir() string {
+	dir := os.Getenv("PIPER_VOICE_DIR")
+	if dir == "" {
+		dir = "./voices"
+	}
+	return dir
+}
+
+// GenerateAudio runs piper against text using options["model"] (falling
+// back to PIPER_DEFAULT_MODEL) and, for multi-speaker models,
+// options["speaker"], then transcodes piper's WAV output to MP3 via ffmpeg
+// so the returned bytes match the format contract of the other providers.
+func (p *PiperProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
+	model := options["model"]
+	if model == "" {
+		model = os.Getenv("PIPER_DEFAULT_MODEL")
+	}
+	if model == "" {
+		return nil, fmt.Errorf(`no piper voice model specified (set options["model"] or PIPER_DEFAULT_MODEL)`)
+	}
+	if strings.ContainsAny(model, `/\`) || model != filepath.Base(model) {
+		return nil, fmt.Errorf("invalid piper voice model %q: must be a bare model name, not a path", model)
+	}
+
+	modelPath := filepath.Join(p.voiceDir, model+".onnx")
+	if _, err := os.Stat(modelPath); err != nil {
+		return nil, fmt.Errorf("piper voice model not found: %s", modelPath)
+	}
+	if _, err := os.Stat(modelPath + ".json"); err != nil {
+		return nil, fmt.Errorf("piper voice config not found: %s.json", modelPath)
+	}
+
+	args := []string{"--model", modelPath, "--output_file", "-"}
+	if speaker := options["speaker"]; speaker != "" {
+		args = append(args, "--speaker", speaker)
+	}
+
+	cmd := exec.CommandContext(ctx, "piper", args...)
+	cmd.Stdin = strings.NewReader(text)
+
+	var wavOut, stderr bytes.Buffer
+	cmd.Stdout = &wavOut
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("piper failed: %v (%s)", err, stderr.String())
+	}
+
+	return wavToMP3(ctx, wavOut.Bytes())
+}
+
+// wavToMP3 transcodes WAV bytes to MP3 by piping them through ffmpeg.
+func wavToMP3(ctx context.Context, wav []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "error",
+		"-f", "wav", "-i", "pipe:0",
+		"-codec:a", "libmp3lame", "-b:a", "128k",
+		"-f", "mp3", "pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(wav)
+
+	var mp3Out, stderr bytes.Buffer
+	cmd.Stdout = &mp3Out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg transcode failed: %v (%s)", err, stderr.String())
+	}
+
+	return mp3Out.Bytes(), nil
+}
+
+// ListVoiceModels returns the names (without the .onnx extension) of every
+// voice model in dir that has both a <name>.onnx file and its
+// <name>.onnx.json config alongside it.
+func ListVoiceModels(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read voice directory %s: %v", dir, err)
+	}
+
+	var models []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".onnx") {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".onnx")
+		if _, err := os.Stat(filepath.Join(dir, base+".onnx.json")); err != nil {
+			continue
+		}
+		models = append(models, base)
+	}
+
+	return models, nil
+}
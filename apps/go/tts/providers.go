@@ -2,6 +2,7 @@ package tts
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -26,7 +28,20 @@ func GetGoogleTTSURL(text, lang string) string {
 
 // TTSProvider defines the interface for text-to-speech providers
 type TTSProvider interface {
-	GenerateAudio(text string, options map[string]string) ([]byte, error)
+	GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error)
+}
+
+// callTimeout returns the per-call deadline from options["timeout"]
+// (seconds), falling back to the 30s every provider used to hard-code in
+// its http.Client. It's applied on top of ctx, so a caller's own
+// cancellation (e.g. a disconnected HTTP client) still takes effect first.
+func callTimeout(options map[string]string) time.Duration {
+	if v := options["timeout"]; v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return 30 * time.Second
 }
 
 // ElevenLabsProvider implements TTSProvider for ElevenLabs
@@ -54,46 +69,102 @@ type ReplicateResponse struct {
 
 // CartesiaTTSProvider implements TTSProvider using Cartesia's API
 type CartesiaTTSProvider struct {
-	folder string
-	apiKey string
+	folder      string
+	apiKey      string
 	rateLimiter *time.Ticker
-	processing bool
-	mutex sync.Mutex
+	sem         chan struct{} // 1-slot semaphore: blocks a second concurrent call instead of failing it
 }
 
 // NewCartesiaTTSProvider creates a new CartesiaTTSProvider instance
 func NewCartesiaTTSProvider(folder string, apiKey string) *CartesiaTTSProvider {
 	return &CartesiaTTSProvider{
-		folder: folder,
-		apiKey: apiKey,
+		folder:      folder,
+		apiKey:      apiKey,
 		rateLimiter: time.NewTicker(500 * time.Millisecond), // Rate limit to 2 requests per second
+		sem:         make(chan struct{}, 1),
 	}
 }
 
-// NewTTSProvider creates a new TTS provider based on the provider name
+// defaultMaxRunes holds each provider's documented hard input-length
+// limit, so a caller can run SplitForTTS(text, MaxRunesFor(provider))
+// before ever calling GenerateAudio.
+var defaultMaxRunes = map[string]int{
+	"elevenlabs": 5000,
+	"together":   5000,
+	"replicate":  5000,
+	"fallback":   200, // Google translate's undocumented TTS endpoint
+	"cartesia":   5000,
+	"piper":      1000,
+}
+
+// MaxRunesFor returns the default maximum input length (in runes) for the
+// named provider, falling back to the same 200-rune limit as the Google
+// translate fallback provider for an unrecognized name.
+func MaxRunesFor(provider string) int {
+	if n, ok := defaultMaxRunes[provider]; ok {
+		return n
+	}
+	return 200
+}
+
+var (
+	defaultCacheOnce sync.Once
+	defaultCache     *Cache
+)
+
+// sharedCache lazily builds the process-wide audio cache, sized by
+// TTS_CACHE_MAX_MB (default 512MB).
+func sharedCache() *Cache {
+	defaultCacheOnce.Do(func() {
+		maxMB := 512
+		if v := os.Getenv("TTS_CACHE_MAX_MB"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				maxMB = n
+			}
+		}
+		defaultCache = NewCache(int64(maxMB) * 1024 * 1024)
+	})
+	return defaultCache
+}
+
+// NewTTSProvider creates a new TTS provider based on the provider name,
+// transparently wrapped with loudness normalization (opt-in per call via
+// options["normalize"]) and the shared content-addressed audio cache, so
+// re-rendering text whose audio is still cached does not hit the provider's
+// API again.
 func NewTTSProvider(provider string, apiKey string) TTSProvider {
+	cache := sharedCache()
+	normalize := func(p TTSProvider) TTSProvider {
+		return WithNormalization(p, EBUR128Normalizer{})
+	}
+
 	switch provider {
 	case "elevenlabs":
-		return &ElevenLabsProvider{apiKey: apiKey}
+		return WithCache(provider, normalize(&ElevenLabsProvider{apiKey: apiKey}), cache)
 	case "together":
-		return &TogetherProvider{apiKey: apiKey}
+		return WithCache(provider, normalize(&TogetherProvider{apiKey: apiKey}), cache)
 	case "replicate":
-		return &ReplicateProvider{apiKey: apiKey}
+		return WithCache(provider, normalize(&ReplicateProvider{apiKey: apiKey}), cache)
 	case "fallback":
-		return &HTGoTTSProvider{folder: "uploads/audio"}
+		return WithCache(provider, normalize(&HTGoTTSProvider{folder: "uploads/audio"}), cache)
 	case "cartesia":
-		return NewCartesiaTTSProvider("uploads/audio", apiKey)
+		return WithCache(provider, normalize(NewCartesiaTTSProvider("uploads/audio", apiKey)), cache)
+	case "piper":
+		return WithCache(provider, normalize(NewPiperProvider(piperVoiceDir())), cache)
 	default:
 		// If no API key is provided, use the fallback provider
 		if apiKey == "" {
-			return &HTGoTTSProvider{folder: "uploads/audio"}
+			return WithCache("fallback", normalize(&HTGoTTSProvider{folder: "uploads/audio"}), cache)
 		}
-		return &ElevenLabsProvider{apiKey: apiKey}
+		return WithCache("elevenlabs", normalize(&ElevenLabsProvider{apiKey: apiKey}), cache)
 	}
 }
 
 // GenerateAudio generates audio using ElevenLabs API
-func (p *ElevenLabsProvider) GenerateAudio(text string, options map[string]string) ([]byte, error) {
+func (p *ElevenLabsProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
 	model := options["model"]
 	if model == "" {
 		model = "eleven_flash_v2_5"
@@ -114,7 +185,7 @@ func (p *ElevenLabsProvider) GenerateAudio(text string, options map[string]strin
 	}
 
 	url := fmt.Sprintf("https://api.elevenlabs.io/v1/text-to-speech/%s/stream?output_format=mp3_44100_128", voice)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -122,7 +193,7 @@ func (p *ElevenLabsProvider) GenerateAudio(text string, options map[string]strin
 	req.Header.Set("xi-api-key", p.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
@@ -138,7 +209,10 @@ func (p *ElevenLabsProvider) GenerateAudio(text string, options map[string]strin
 }
 
 // GenerateAudio generates audio using Together AI API
-func (p *TogetherProvider) GenerateAudio(text string, options map[string]string) ([]byte, error) {
+func (p *TogetherProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
 	model := options["model"]
 	if model == "" {
 		model = "Cartesia/Sonic"
@@ -159,7 +233,7 @@ func (p *TogetherProvider) GenerateAudio(text string, options map[string]string)
 	}
 
 	url := fmt.Sprintf("https://api.together.xyz/inference/%s", model)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -167,7 +241,7 @@ func (p *TogetherProvider) GenerateAudio(text string, options map[string]string)
 	req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
@@ -183,7 +257,10 @@ func (p *TogetherProvider) GenerateAudio(text string, options map[string]string)
 }
 
 // GenerateAudio generates audio using Replicate API
-func (p *ReplicateProvider) GenerateAudio(text string, options map[string]string) ([]byte, error) {
+func (p *ReplicateProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
 	model := options["model"]
 	if model == "" {
 		model = "jaaari/kokoro-82m"
@@ -207,7 +284,7 @@ func (p *ReplicateProvider) GenerateAudio(text string, options map[string]string
 	}
 
 	url := "https://api.replicate.com/v1/predictions"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -215,7 +292,7 @@ func (p *ReplicateProvider) GenerateAudio(text string, options map[string]string
 	req.Header.Set("Authorization", "Token "+p.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
@@ -234,9 +311,13 @@ func (p *ReplicateProvider) GenerateAudio(text string, options map[string]string
 
 	// Poll until the prediction is complete
 	for result.Status == "processing" {
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 
-		req, err = http.NewRequest("GET", fmt.Sprintf("https://api.replicate.com/v1/predictions/%s", result.ID), nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.replicate.com/v1/predictions/%s", result.ID), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create status request: %v", err)
 		}
@@ -263,7 +344,7 @@ func (p *ReplicateProvider) GenerateAudio(text string, options map[string]string
 	}
 
 	// Download the audio file from the output URL
-	req, err = http.NewRequest("GET", result.Output, nil)
+	req, err = http.NewRequestWithContext(ctx, "GET", result.Output, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create download request: %v", err)
 	}
@@ -293,7 +374,10 @@ func NewHTGoTTSProvider(folder string) *HTGoTTSProvider {
 	}
 }
 
-func (p *HTGoTTSProvider) GenerateAudio(text string, options map[string]string) ([]byte, error) {
+func (p *HTGoTTSProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
 	// Ensure the audio folder exists
 	if err := os.MkdirAll(p.folder, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create audio folder: %v", err)
@@ -317,7 +401,11 @@ func (p *HTGoTTSProvider) GenerateAudio(text string, options map[string]string)
 	url := GetGoogleTTSURL(text, "en")
 
 	// Download the audio file
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download audio: %v", err)
 	}
@@ -340,23 +428,27 @@ func (p *HTGoTTSProvider) GenerateAudio(text string, options map[string]string)
 	return audioData, nil
 }
 
-func (p *CartesiaTTSProvider) GenerateAudio(text string, options map[string]string) ([]byte, error) {
-	p.mutex.Lock()
-	if p.processing {
-		p.mutex.Unlock()
-		return nil, fmt.Errorf("another request is being processed")
+func (p *CartesiaTTSProvider) GenerateAudio(ctx context.Context, text string, options map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout(options))
+	defer cancel()
+
+	// Block on the 1-slot semaphore instead of failing outright, so a second
+	// concurrent call on this same provider instance waits instead of
+	// erroring; ctx cancellation (client disconnect, timeout) still unblocks
+	// the wait. Instances from separate NewTTSProvider calls do not share it.
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	p.processing = true
-	p.mutex.Unlock()
+	defer func() { <-p.sem }()
 
 	// Wait for rate limiter
-	<-p.rateLimiter.C
-
-	defer func() {
-		p.mutex.Lock()
-		p.processing = false
-		p.mutex.Unlock()
-	}()
+	select {
+	case <-p.rateLimiter.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
 	// Ensure the audio folder exists
 	if err := os.MkdirAll(p.folder, 0755); err != nil {
@@ -405,7 +497,7 @@ func (p *CartesiaTTSProvider) GenerateAudio(text string, options map[string]stri
 	}
 
 	// Create the request
-	req, err := http.NewRequest("POST", "https://api.cartesia.ai/tts/bytes", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.cartesia.ai/tts/bytes", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -416,7 +508,7 @@ func (p *CartesiaTTSProvider) GenerateAudio(text string, options map[string]stri
 	req.Header.Set("Cartesia-Version", "2025-04-16")
 
 	// Make the request
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
@@ -0,0 +1,160 @@
+package tts
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// sentenceEnders are the punctuation marks SplitForTTS treats as ending a
+// sentence, covering both ASCII and the CJK full-width equivalents.
+var sentenceEnders = map[rune]bool{
+	'.': true, '!': true, '?': true, ':': true, ';': true,
+	'。': true, '！': true, '？': true,
+}
+
+var splitWhitespaceRe = regexp.MustCompile(`\s+`)
+
+// SplitForTTS splits text into chunks of at most maxRunes runes so it can
+// be fed to a provider with a hard input limit (Google translate ~200
+// chars, ElevenLabs ~5000, Cartesia its own). Sentences are packed
+// greedily -- several short sentences may share a chunk -- and a cut
+// normally lands after sentence-ending punctuation followed by whitespace
+// or end-of-input. A single sentence longer than maxRunes falls back to
+// comma boundaries, a single clause longer than maxRunes falls back to word
+// boundaries, and a single word longer than maxRunes is cut mid-word into
+// maxRunes-rune pieces.
+func SplitForTTS(text string, maxRunes int) []string {
+	if maxRunes <= 0 {
+		return nil
+	}
+
+	normalized := strings.TrimSpace(splitWhitespaceRe.ReplaceAllString(text, " "))
+	if normalized == "" {
+		return nil
+	}
+
+	return packUnits(splitSentences(normalized), maxRunes, splitLongSentence)
+}
+
+// splitSentences cuts text after each sentence-ending punctuation mark
+// that is followed by whitespace or end-of-input.
+func splitSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+
+	for i, r := range runes {
+		atEnd := i == len(runes)-1
+		followedByBreak := atEnd || unicode.IsSpace(runes[i+1])
+
+		if sentenceEnders[r] && followedByBreak {
+			if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		} else if atEnd {
+			if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+		}
+	}
+
+	return sentences
+}
+
+// splitLongSentence packs a single over-limit sentence into maxRunes
+// chunks at comma boundaries, falling further back to splitWords for any
+// clause that is itself still too long.
+func splitLongSentence(sentence string, maxRunes int) []string {
+	return packUnits(splitClauses(sentence), maxRunes, splitWords)
+}
+
+// splitClauses splits sentence at commas, keeping each comma with the
+// clause it terminates.
+func splitClauses(sentence string) []string {
+	runes := []rune(sentence)
+	var clauses []string
+	start := 0
+
+	for i, r := range runes {
+		if r == ',' {
+			if clause := strings.TrimSpace(string(runes[start : i+1])); clause != "" {
+				clauses = append(clauses, clause)
+			}
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		clauses = append(clauses, rest)
+	}
+
+	return clauses
+}
+
+// splitWords packs a clause into maxRunes chunks at space boundaries,
+// falling back to hardSplit for any single word that is itself too long
+// (e.g. a long URL with no internal breaks).
+func splitWords(clause string, maxRunes int) []string {
+	return packUnits(strings.Fields(clause), maxRunes, hardSplit)
+}
+
+// hardSplit cuts a single over-limit token into maxRunes-rune pieces as an
+// absolute last resort.
+func hardSplit(word string, maxRunes int) []string {
+	runes := []rune(word)
+	var pieces []string
+	for i := 0; i < len(runes); i += maxRunes {
+		end := i + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+		}
+		pieces = append(pieces, string(runes[i:end]))
+	}
+	return pieces
+}
+
+// packUnits greedily joins units (sentences, clauses, or words), one space
+// apart, into chunks of at most maxRunes runes. A unit that alone exceeds
+// maxRunes is expanded via split and its pieces packed in its place.
+func packUnits(units []string, maxRunes int, split func(string, int) []string) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, unit := range units {
+		unitLen := len([]rune(unit))
+
+		if unitLen > maxRunes {
+			flush()
+			chunks = append(chunks, split(unit, maxRunes)...)
+			continue
+		}
+
+		extra := unitLen
+		if currentLen > 0 {
+			extra++ // the joining space
+		}
+		if currentLen+extra > maxRunes {
+			flush()
+		}
+
+		if currentLen > 0 {
+			current.WriteByte(' ')
+			currentLen++
+		}
+		current.WriteString(unit)
+		currentLen += unitLen
+	}
+	flush()
+
+	return chunks
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucsky/cuid"
+)
+
+// maxConcurrentParts bounds how many PATCH /upload/:uploadId/parts/:n
+// requests a single upload may have in flight at once, so one flaky client
+// can't starve the temp-file I/O for everyone else.
+const maxConcurrentParts = 4
+
+// pendingUpload tracks a multipart PDF upload in progress. Its pdf_uploads
+// row records the filename, total size, declared sha256 and status, and
+// each accepted part only bumps updated_at there; the received part numbers
+// and byte count live in memory alone. Handlers look uploads up in the
+// in-memory uploads map only, so an upload does not survive a server restart.
+type pendingUpload struct {
+	mutex          sync.Mutex
+	id             string
+	pdfId          string
+	filename       string
+	tempPath       string
+	totalBytes     int64
+	receivedSet    map[int]bool
+	receivedBytes  int64
+	expectedSha256 string // sha256 the client declared at init, checked on complete
+	partsSem       chan struct{}
+}
+
+var (
+	uploadsMutex sync.Mutex
+	uploads      = make(map[string]*pendingUpload)
+)
+
+type initUploadRequest struct {
+	Filename   string `json:"filename"`
+	PdfId      string `json:"pdfId"`
+	TotalBytes int64  `json:"totalBytes"`
+	Sha256     string `json:"sha256"`
+}
+
+type initUploadResponse struct {
+	UploadId string `json:"uploadId"`
+}
+
+// initUploadHandler starts a new resumable upload and records it in
+// pdf_uploads.
+func initUploadHandler(c *gin.Context) {
+	var req initUploadRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Filename == "" || req.PdfId == "" || req.TotalBytes <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filename, pdfId, and totalBytes are required"})
+		return
+	}
+
+	uploadsDir := "./uploads/incoming"
+	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
+		return
+	}
+
+	uploadId := cuid.New()
+	tempPath := path.Join(uploadsDir, uploadId+".part")
+
+	f, err := os.Create(tempPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate upload file"})
+		return
+	}
+	if err := f.Truncate(req.TotalBytes); err != nil {
+		f.Close()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate upload file"})
+		return
+	}
+	f.Close()
+
+	pu := &pendingUpload{
+		id:             uploadId,
+		pdfId:          req.PdfId,
+		filename:       req.Filename,
+		tempPath:       tempPath,
+		totalBytes:     req.TotalBytes,
+		receivedSet:    make(map[int]bool),
+		expectedSha256: req.Sha256,
+		partsSem:       make(chan struct{}, maxConcurrentParts),
+	}
+
+	uploadsMutex.Lock()
+	uploads[uploadId] = pu
+	uploadsMutex.Unlock()
+
+	if db != nil {
+		_, err := db.Exec(context.Background(),
+			`INSERT INTO pdf_uploads (id, pdf_id, filename, total_bytes, sha256, received_parts, status, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			uploadId, req.PdfId, req.Filename, req.TotalBytes, req.Sha256, "{}", "pending", time.Now(), time.Now(),
+		)
+		if err != nil {
+			log.Printf("Failed to persist pdf_uploads row for %s: %v", uploadId, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, initUploadResponse{UploadId: uploadId})
+}
+
+// uploadPartHandler writes one byte range of the PDF into the pre-allocated
+// temp file at the offset given by the "Content-Range: bytes start-end/total"
+// header, mirroring the streaming-PATCH pattern used by the Docker registry
+// blob upload API.
+func uploadPartHandler(c *gin.Context) {
+	uploadId := c.Param("uploadId")
+	partStr := c.Param("n")
+	partNum, err := strconv.Atoi(partStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid part number"})
+		return
+	}
+
+	uploadsMutex.Lock()
+	pu, ok := uploads[uploadId]
+	uploadsMutex.Unlock()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown uploadId"})
+		return
+	}
+
+	start, end, _, err := parseContentRange(c.GetHeader("Content-Range"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pu.partsSem <- struct{}{}
+	defer func() { <-pu.partsSem }()
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read part body"})
+		return
+	}
+	if int64(len(data)) != end-start+1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "part size does not match Content-Range"})
+		return
+	}
+
+	f, err := os.OpenFile(pu.tempPath, os.O_WRONLY, 0644)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open upload file"})
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteAt(data, start); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write part"})
+		return
+	}
+
+	pu.mutex.Lock()
+	if !pu.receivedSet[partNum] {
+		pu.receivedSet[partNum] = true
+		pu.receivedBytes += end - start + 1
+	}
+	receivedCount := len(pu.receivedSet)
+	pu.mutex.Unlock()
+
+	if db != nil {
+		_, err := db.Exec(context.Background(),
+			`UPDATE pdf_uploads SET updated_at = $1 WHERE id = $2`, time.Now(), uploadId,
+		)
+		if err != nil {
+			log.Printf("Failed to update pdf_uploads progress for %s: %v", uploadId, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": receivedCount})
+}
+
+// completeUploadHandler verifies the assembled file, then runs the same
+// extraction, chunking, DB insert, and TTS scheduling as the single-shot
+// uploadHandler and returns the same UploadResponse shape.
+func completeUploadHandler(c *gin.Context) {
+	uploadId := c.Param("uploadId")
+
+	uploadsMutex.Lock()
+	pu, ok := uploads[uploadId]
+	uploadsMutex.Unlock()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown uploadId"})
+		return
+	}
+
+	pu.mutex.Lock()
+	receivedBytes := pu.receivedBytes
+	pu.mutex.Unlock()
+	if receivedBytes < pu.totalBytes {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("upload incomplete: received %d of %d bytes", receivedBytes, pu.totalBytes),
+		})
+		return
+	}
+
+	sum, err := sha256File(pu.tempPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to checksum upload"})
+		return
+	}
+	if pu.expectedSha256 != "" && !strings.EqualFold(sum, pu.expectedSha256) {
+		c.JSON(http.StatusConflict, gin.H{"error": "checksum mismatch: uploaded file does not match declared sha256"})
+		return
+	}
+
+	uploadsDir := "./uploads"
+	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
+		return
+	}
+
+	baseFilename := regexp.MustCompile(`\.pdf$`).ReplaceAllString(pu.filename, "")
+	cleanFilename := regexp.MustCompile(`[^a-zA-Z0-9]+`).ReplaceAllString(baseFilename, "_")
+	finalPath := path.Join(uploadsDir, cleanFilename+".pdf")
+
+	if err := os.Rename(pu.tempPath, finalPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to finalize upload"})
+		return
+	}
+
+	settings := TTSSettings{
+		Provider: c.GetHeader("X-TTS-Provider"),
+		APIKey:   c.GetHeader("X-TTS-API-Key"),
+		Model:    c.GetHeader("X-TTS-Model"),
+		Voice:    c.GetHeader("X-TTS-Voice"),
+	}
+	if settings.Provider == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "TTS provider is required"})
+		return
+	}
+
+	resp, err := processUploadedPDF(finalPath, cleanFilename, pu.pdfId, requestIDFromContext(c), settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	uploadsMutex.Lock()
+	delete(uploads, uploadId)
+	uploadsMutex.Unlock()
+
+	if db != nil {
+		_, err := db.Exec(context.Background(),
+			`UPDATE pdf_uploads SET status = $1, sha256 = $2, updated_at = $3 WHERE id = $4`,
+			"complete", sum, time.Now(), uploadId,
+		)
+		if err != nil {
+			log.Printf("Failed to mark pdf_uploads %s complete: %v", uploadId, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// abortUploadHandler cancels an in-progress upload and cleans up its temp file.
+func abortUploadHandler(c *gin.Context) {
+	uploadId := c.Param("uploadId")
+
+	uploadsMutex.Lock()
+	pu, ok := uploads[uploadId]
+	if ok {
+		delete(uploads, uploadId)
+	}
+	uploadsMutex.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown uploadId"})
+		return
+	}
+
+	os.Remove(pu.tempPath)
+
+	if db != nil {
+		_, err := db.Exec(context.Background(),
+			`UPDATE pdf_uploads SET status = $1, updated_at = $2 WHERE id = $3`,
+			"aborted", time.Now(), uploadId,
+		)
+		if err != nil {
+			log.Printf("Failed to mark pdf_uploads %s aborted: %v", uploadId, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "upload aborted"})
+}
+
+// processUploadedPDF extracts text, chunks it, persists the chunks, and
+// kicks off TTS generation for a PDF that has already been saved to disk.
+// It's shared by the single-shot uploadHandler and the multipart complete step.
+// requestID is the originating HTTP call's request_id, so a TTS failure in
+// the background goroutines ProcessChunks spawns can still be traced back to it.
+func processUploadedPDF(filepath, cleanFilename, pdfId, requestID string, settings TTSSettings) (UploadResponse, error) {
+	text, err := extractTextFromPDF(filepath)
+	if err != nil {
+		return UploadResponse{}, fmt.Errorf("failed to extract text from PDF: %v", err)
+	}
+
+	chunks := chunkText(text, settings.Provider)
+	if len(chunks) == 0 {
+		return UploadResponse{}, fmt.Errorf("no text found in PDF")
+	}
+
+	if settings.Provider != "fallback" && settings.APIKey == "" {
+		return UploadResponse{}, fmt.Errorf("API key is required for non-fallback providers")
+	}
+	if pdfId == "" {
+		return UploadResponse{}, fmt.Errorf("pdfId is required")
+	}
+
+	processor.pdfId = pdfId
+	processor.requestID = requestID
+
+	chunkIDs := make([]string, len(chunks))
+	for i := range chunks {
+		chunkIDs[i] = cuid.New()
+	}
+
+	var audioID string
+	if processor.sqsClient != nil {
+		// Distributed mode: publish the work and let RunWorker pods (run
+		// separately with WORKER_MODE=1) generate and upload the audio.
+		if err := processor.EnqueueChunks(chunks, chunkIDs, cleanFilename, settings); err != nil {
+			return UploadResponse{}, fmt.Errorf("failed to enqueue chunks: %v", err)
+		}
+		audioID = chunkIDs[0]
+	} else {
+		audioID = processor.ProcessChunks(chunks, cleanFilename, settings)
+	}
+
+	for idx, chunkText := range chunks {
+		_, err := db.Exec(context.Background(),
+			`INSERT INTO pdf_chunks (id, pdf_id, index, text, audio_url, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			chunkIDs[idx], pdfId, idx, chunkText, nil, time.Now(), time.Now(),
+		)
+		if err != nil {
+			return UploadResponse{}, fmt.Errorf("failed to save chunks to DB: %v", err)
+		}
+	}
+
+	go func() {
+		time.Sleep(24 * time.Hour)
+		os.Remove(filepath)
+	}()
+
+	return UploadResponse{
+		Message: "PDF processed successfully",
+		Chunks:  chunks,
+		AudioID: audioID,
+	}, nil
+}
+
+func sha256File(filepath string) (string, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseContentRange parses a "bytes start-end/total" Content-Range header.
+func parseContentRange(header string) (start, end, total int64, err error) {
+	var s, e, t int64
+	n, err := fmt.Sscanf(header, "bytes %d-%d/%d", &s, &e, &t)
+	if err != nil || n != 3 {
+		return 0, 0, 0, fmt.Errorf("invalid or missing Content-Range header")
+	}
+	return s, e, t, nil
+}